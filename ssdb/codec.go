@@ -0,0 +1,134 @@
+package ssdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec encodes the values of GetAs, SetAs, HashGetAs and HashSetAs. Set
+// it with WithCodec or Client.SetCodec, other formats such as msgpack or
+// protobuf are added by wrapping their Marshal and Unmarshal functions.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes the values with encoding/json.
+var JSONCodec Codec = jsonCodec{}
+
+// GobCodec encodes the values with encoding/gob, each value carries its
+// type description.
+var GobCodec Codec = gobCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Codec returns the codec of the client, JSONCodec when none was set.
+func (c *Client) Codec() Codec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.opts.codec == nil {
+		return JSONCodec
+	}
+	return c.opts.codec
+}
+
+// SetCodec sets the codec of the client, see WithCodec.
+func (c *Client) SetCodec(codec Codec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts.codec = codec
+}
+
+// CodecClient is a client whose values can be encoded with its codec:
+// Client, NamespacedClient and the clients embedding a Client, e.g.
+// ShardedClient.
+type CodecClient interface {
+	Codec() Codec
+	GetContext(ctx context.Context, key string) (string, error)
+	SetContext(ctx context.Context, key string, val string) error
+	HashGetContext(ctx context.Context, hash string, key string) (string, error)
+	HashSetContext(ctx context.Context, hash string, key string, val string) error
+}
+
+// GetAs returns the value of key decoded by the codec of c.
+//
+//	user, err := ssdb.GetAs[User](db, "user:1")
+func GetAs[T any](c CodecClient, key string) (T, error) {
+	return GetAsContext[T](context.Background(), c, key)
+}
+
+func GetAsContext[T any](ctx context.Context, c CodecClient, key string) (T, error) {
+	var v T
+	val, err := c.GetContext(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	err = c.Codec().Unmarshal([]byte(val), &v)
+	return v, err
+}
+
+// SetAs sets key to v encoded by the codec of c.
+func SetAs[T any](c CodecClient, key string, v T) error {
+	return SetAsContext(context.Background(), c, key, v)
+}
+
+func SetAsContext[T any](ctx context.Context, c CodecClient, key string, v T) error {
+	data, err := c.Codec().Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.SetContext(ctx, key, string(data))
+}
+
+// HashGetAs returns the value of the field key of hash decoded by the codec
+// of c.
+func HashGetAs[T any](c CodecClient, hash string, key string) (T, error) {
+	return HashGetAsContext[T](context.Background(), c, hash, key)
+}
+
+func HashGetAsContext[T any](ctx context.Context, c CodecClient, hash string, key string) (T, error) {
+	var v T
+	val, err := c.HashGetContext(ctx, hash, key)
+	if err != nil {
+		return v, err
+	}
+	err = c.Codec().Unmarshal([]byte(val), &v)
+	return v, err
+}
+
+// HashSetAs sets the field key of hash to v encoded by the codec of c.
+func HashSetAs[T any](c CodecClient, hash string, key string, v T) error {
+	return HashSetAsContext(context.Background(), c, hash, key, v)
+}
+
+func HashSetAsContext[T any](ctx context.Context, c CodecClient, hash string, key string, v T) error {
+	data, err := c.Codec().Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.HashSetContext(ctx, hash, key, string(data))
+}
@@ -0,0 +1,154 @@
+package ssdb_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+)
+
+type user struct {
+	Name  string
+	Age   int
+	Roles []string
+}
+
+// upperCodec stores strings upper-cased, to tell its values apart.
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("upperCodec: not a string")
+	}
+	return []byte(strings.ToUpper(s)), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	p, ok := v.(*string)
+	if !ok {
+		return errors.New("upperCodec: not a *string")
+	}
+	*p = strings.ToLower(string(data))
+	return nil
+}
+
+func TestCodecs(t *testing.T) {
+	in := user{Name: "ann", Age: 30, Roles: []string{"admin"}}
+	for _, tt := range []struct {
+		name  string
+		codec ssdb.Codec
+	}{
+		{"json", ssdb.JSONCodec},
+		{"gob", ssdb.GobCodec},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t)
+			c := dial(t, srv, ssdb.WithCodec(tt.codec))
+			if c.Codec() != tt.codec {
+				t.Fatalf("Codec = %v, want %v", c.Codec(), tt.codec)
+			}
+			if err := ssdb.SetAs(c, "u", in); err != nil {
+				t.Fatal(err)
+			}
+			if out, err := ssdb.GetAs[user](c, "u"); err != nil || !reflect.DeepEqual(out, in) {
+				t.Fatalf("GetAs = %+v, %v, want %+v", out, err, in)
+			}
+			if err := ssdb.HashSetAs(c, "h", "u", in); err != nil {
+				t.Fatal(err)
+			}
+			if out, err := ssdb.HashGetAs[user](c, "h", "u"); err != nil || !reflect.DeepEqual(out, in) {
+				t.Fatalf("HashGetAs = %+v, %v, want %+v", out, err, in)
+			}
+			// The value stored is the encoding of the codec.
+			raw, err := c.Get("u")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := tt.codec.Marshal(in)
+			if err != nil || raw != string(data) {
+				t.Fatalf("stored %q, want %q", raw, data)
+			}
+		})
+	}
+}
+
+func TestCodecDefault(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if c.Codec() != ssdb.JSONCodec {
+		t.Fatalf("Codec = %v, want JSONCodec", c.Codec())
+	}
+	if err := ssdb.SetAs(c, "n", 42); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get("n"); err != nil || v != "42" {
+		t.Fatalf("Get = %q, %v, want the JSON 42", v, err)
+	}
+}
+
+func TestCodecPerClient(t *testing.T) {
+	srv := newServer(t)
+	plain := dial(t, srv)
+	custom := dial(t, srv)
+	custom.SetCodec(upperCodec{})
+	if err := ssdb.SetAs(custom, "k", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := plain.Get("k"); err != nil || v != "HELLO" {
+		t.Fatalf("Get = %q, %v, want the value of the custom codec", v, err)
+	}
+	if v, err := ssdb.GetAs[string](custom, "k"); err != nil || v != "hello" {
+		t.Fatalf("GetAs = %q, %v", v, err)
+	}
+	if err := ssdb.SetAs(plain, "j", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := plain.Get("j"); err != nil || v != `"hello"` {
+		t.Fatalf("Get = %q, %v, want the JSON of the other client", v, err)
+	}
+	if err := ssdb.SetAs(custom, "k", 1); err == nil {
+		t.Fatal("SetAs returned no error of the codec")
+	}
+
+	// A namespace uses the codec of its client.
+	n := ssdb.Namespaced(custom, "svc:")
+	if err := ssdb.SetAs(n, "k", "world"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := plain.Get("svc:k"); err != nil || v != "WORLD" {
+		t.Fatalf("Get = %q, %v, want the value of the custom codec", v, err)
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if _, err := ssdb.GetAs[user](c, "missing"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("GetAs of a missing key: %v, want ErrNotFound", err)
+	}
+	if _, err := ssdb.HashGetAs[user](c, "h", "missing"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("HashGetAs of a missing field: %v, want ErrNotFound", err)
+	}
+	if err := c.Set("bad", "{not json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ssdb.GetAs[user](c, "bad"); err == nil || errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("GetAs of a bad value: %v, want a decode error", err)
+	}
+	if err := c.HashSet("h", "bad", "[1,2]"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ssdb.HashGetAs[user](c, "h", "bad"); err == nil {
+		t.Fatal("HashGetAs decoded an array into a struct")
+	}
+	c.SetCodec(ssdb.GobCodec)
+	if _, err := ssdb.GetAs[user](c, "bad"); err == nil {
+		t.Fatal("GetAs decoded JSON with the gob codec")
+	}
+	if err := ssdb.SetAs(c, "f", func() {}); err == nil {
+		t.Fatal("SetAs encoded a func")
+	}
+}
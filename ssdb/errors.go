@@ -0,0 +1,133 @@
+package ssdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+// Status codes of SSDB responses.
+const (
+	StatusOK          = "ok"
+	StatusNotFound    = "not_found"
+	StatusError       = "error"
+	StatusFail        = "fail"
+	StatusClientError = "client_error"
+)
+
+// ErrNotFound matches the error of a command on a key, hash field or sorted
+// set member that does not exist. Use errors.Is to check for it.
+var ErrNotFound = errors.New("not_found")
+
+// ErrClosed is returned when a command is sent on a closed or reconnecting
+// connection.
+var ErrClosed = errors.New("Connection has closed.")
+
+// ErrNoShards is returned by a ShardedClient that has no shard.
+var ErrNoShards = errors.New("ssdb: no shards")
+
+// ErrNoMaster is returned by NewFailoverClient when no candidate is a
+// master.
+var ErrNoMaster = errors.New("ssdb: no master")
+
+// Error describes a failed command. Status is the status code of the
+// response, it is empty when the command failed on the connection and Err
+// holds the cause.
+type Error struct {
+	Status    string
+	Message   string
+	Cmd       string
+	Retryable bool
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Status == "" {
+		return fmt.Sprintf("ssdb %s: %v", e.Cmd, e.Err)
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("ssdb %s: %s", e.Cmd, e.Status)
+	}
+	return fmt.Sprintf("ssdb %s: %s: %s", e.Cmd, e.Status, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrNotFound) true for not_found responses.
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && e.Status == StatusNotFound
+}
+
+// IsRetryable reports whether the command failed on the connection or on a
+// transient server error and may succeed when sent again.
+func IsRetryable(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Retryable
+	}
+	return false
+}
+
+// IsConnError reports whether err means the connection is broken and has to
+// be reconnected.
+func IsConnError(err error) bool {
+	var e *Error
+	if errors.As(err, &e) && e.Status != "" {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, ErrClosed) {
+		return true
+	}
+	if errors.Is(err, proto.ErrProtocol) || errors.Is(err, proto.ErrPacketTooLarge) {
+		return true
+	}
+	// context.DeadlineExceeded is a net.Error too, an expired caller does
+	// not break the connection.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryableStatus holds the status codes of the responses that may succeed
+// when the command is sent again: error is a failure of the server, fail,
+// client_error and not_found depend on the command and its arguments.
+var retryableStatus = map[string]bool{
+	StatusError: true,
+}
+
+// responseError converts a response with a status other than ok.
+func responseError(cmd string, resp []string) *Error {
+	e := &Error{Cmd: cmd, Status: StatusError, Message: "empty response"}
+	if len(resp) > 0 {
+		e.Status = resp[0]
+		e.Message = strings.Join(resp[1:], " ")
+	}
+	e.Retryable = retryableStatus[e.Status]
+	return e
+}
+
+// connError wraps an error raised while sending or receiving cmd.
+func connError(cmd string, err error) error {
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	retryable := !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	return &Error{Cmd: cmd, Err: err, Retryable: retryable}
+}
+
+// cmdName returns the command name of args.
+func cmdName(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return fmt.Sprint(args[0])
+}
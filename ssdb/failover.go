@@ -0,0 +1,264 @@
+package ssdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailoverConfig controls a FailoverClient.
+type FailoverConfig struct {
+	// Addrs are the candidate servers in the "host:port" form, the first
+	// master in this order is used.
+	Addrs []string
+	// Options are used to dial the servers.
+	Options []Option
+	// ProbeInterval is the time between two checks of the master, 1s when
+	// 0. A command failing on the connection of the master checks it at
+	// once.
+	ProbeInterval time.Duration
+	// ProbeTimeout bounds the dial and the info command of a check, 1s
+	// when 0.
+	ProbeTimeout time.Duration
+	// OnFailover is called when the client moved to another master.
+	OnFailover func(ev FailoverEvent)
+	// Logger receives the log messages of the failover client.
+	Logger Logger
+	// Hooks are called around the commands of the failover client, the
+	// hooks given in Options are called for the commands of the master.
+	Hooks []Hook
+}
+
+// FailoverEvent describes a move of a FailoverClient to another master.
+type FailoverEvent struct {
+	// From and To are the addresses of the previous and the new master.
+	From string
+	To   string
+	// Err is the error of the check that found the previous master gone.
+	Err  error
+	Time time.Time
+}
+
+// FailoverClient sends the commands to the master among a list of candidate
+// servers, and moves to another master when the master is lost. The role of
+// a server is read from the replication section of its info: a server that
+// is the slave of another one with type sync is not a master, a server
+// without slaveof or with type mirror is.
+//
+// The master is checked every ProbeInterval. When it is unreachable or
+// became a slave, the candidates are probed and the first master found
+// replaces it. The commands that failed meanwhile are not retried. SSDB
+// slaves are promoted by their configuration, the client follows the
+// promotion and does not make it.
+//
+// The embedded Client has the helpers, Do, ProcessCmd, Pipeline and the
+// hooks of a Client, and can be given to Namespaced.
+type FailoverClient struct {
+	*Client
+	config FailoverConfig
+	mu     sync.RWMutex
+	master *Client
+	addr   string
+	check  chan struct{}
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewFailoverClient connects to the first master among config.Addrs, it
+// returns ErrNoMaster when no candidate is a master.
+func NewFailoverClient(config FailoverConfig) (*FailoverClient, error) {
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = time.Second
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = time.Second
+	}
+	config.Addrs = append([]string(nil), config.Addrs...)
+	f := &FailoverClient{
+		config: config,
+		check:  make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+	o := defaultOptions()
+	o.logger = config.Logger
+	o.hooks = config.Hooks
+	f.Client = newRoutedClient(f, o)
+	addr, err := f.elect()
+	if err != nil {
+		return nil, err
+	}
+	master, err := Dial(addr, config.Options...)
+	if err != nil {
+		return nil, err
+	}
+	f.master, f.addr = master, addr
+	f.wg.Add(1)
+	go f.monitor()
+	return f, nil
+}
+
+// Master returns the client of the master and its address.
+func (f *FailoverClient) Master() (*Client, string) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.master, f.addr
+}
+
+// Close closes the client and its master.
+func (f *FailoverClient) Close() error {
+	f.Client.Close()
+	f.mu.Lock()
+	select {
+	case <-f.stop:
+	default:
+		close(f.stop)
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+	master, _ := f.Master()
+	return master.Close()
+}
+
+func (f *FailoverClient) monitor() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.config.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		case <-f.check:
+		}
+		f.failover()
+	}
+}
+
+// failover checks the master and moves to another one when it is gone.
+func (f *FailoverClient) failover() {
+	master, from := f.Master()
+	ctx, cancel := context.WithTimeout(context.Background(), f.config.ProbeTimeout)
+	resp, err := master.DoContext(ctx, "info")
+	cancel()
+	if err == nil {
+		err = masterError(from, resp)
+	}
+	if err == nil {
+		return
+	}
+	f.debugLog("master check failed", "addr", from, "err", err)
+	to, electErr := f.elect()
+	if electErr != nil {
+		f.logger().Warn("master lost, no other master", "addr", from, "err", err)
+		return
+	}
+	if to == from {
+		return
+	}
+	next, dialErr := Dial(to, f.config.Options...)
+	if dialErr != nil {
+		f.logger().Warn("failover failed", "addr", to, "err", dialErr)
+		return
+	}
+	f.mu.Lock()
+	select {
+	case <-f.stop:
+		f.mu.Unlock()
+		next.Close()
+		return
+	default:
+	}
+	f.master, f.addr = next, to
+	f.mu.Unlock()
+	master.Close()
+	f.logger().Warn("failover", "from", from, "to", to, "err", err)
+	if f.config.OnFailover != nil {
+		f.config.OnFailover(FailoverEvent{From: from, To: to, Err: err, Time: time.Now()})
+	}
+}
+
+// elect probes the candidates and returns the first master.
+func (f *FailoverClient) elect() (string, error) {
+	errs := make([]error, len(f.config.Addrs))
+	var wg sync.WaitGroup
+	wg.Add(len(f.config.Addrs))
+	for i, addr := range f.config.Addrs {
+		go func(i int, addr string) {
+			defer wg.Done()
+			errs[i] = f.probe(addr)
+		}(i, addr)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err == nil {
+			return f.config.Addrs[i], nil
+		}
+		f.debugLog("candidate is not a master", "addr", f.config.Addrs[i], "err", err)
+	}
+	return "", ErrNoMaster
+}
+
+// probe returns nil when the server at addr is a master.
+func (f *FailoverClient) probe(addr string) error {
+	opts := append(f.config.Options[:len(f.config.Options):len(f.config.Options)],
+		WithDialTimeout(f.config.ProbeTimeout), WithHealthCheckInterval(0))
+	c, err := Dial(addr, opts...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), f.config.ProbeTimeout)
+	defer cancel()
+	resp, err := c.DoContext(ctx, "info")
+	if err != nil {
+		return err
+	}
+	return masterError(addr, resp)
+}
+
+// masterError returns why the info response resp of the server at addr is
+// not the one of a master.
+func masterError(addr string, resp []string) error {
+	if len(resp) == 0 || resp[0] != StatusOK {
+		return responseError("info", resp)
+	}
+	for i := 1; i+1 < len(resp); i++ {
+		if resp[i] != "replication" || !strings.HasPrefix(resp[i+1], "slaveof ") {
+			continue
+		}
+		lines := strings.Split(resp[i+1], "\n")
+		for _, line := range lines[1:] {
+			name, val, ok := strings.Cut(line, ":")
+			if ok && strings.TrimSpace(name) == "type" && strings.TrimSpace(val) == "sync" {
+				return fmt.Errorf("ssdb: %s is a slave of %s", addr, strings.TrimSpace(strings.TrimPrefix(lines[0], "slaveof ")))
+			}
+		}
+	}
+	return nil
+}
+
+// kick checks the master at once when err is a connection error.
+func (f *FailoverClient) kick(err error) {
+	if err != nil && IsConnError(err) {
+		select {
+		case f.check <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *FailoverClient) do(ctx context.Context, args []interface{}) ([]string, error) {
+	master, _ := f.Master()
+	resp, err := master.DoContext(ctx, args...)
+	f.kick(err)
+	return resp, err
+}
+
+func (f *FailoverClient) exec(ctx context.Context, cmds []*Cmd) error {
+	master, _ := f.Master()
+	err := execOn(ctx, master, cmds)
+	f.kick(err)
+	return err
+}
@@ -0,0 +1,99 @@
+package ssdb_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+func TestFailover(t *testing.T) {
+	a, err := ssdbtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b := newServer(t)
+	b.SetSlaveOf(a.Addr)
+
+	events := make(chan ssdb.FailoverEvent, 4)
+	f, err := ssdb.NewFailoverClient(ssdb.FailoverConfig{
+		Addrs:         []string{b.Addr, a.Addr},
+		ProbeInterval: 20 * time.Millisecond,
+		ProbeTimeout:  200 * time.Millisecond,
+		OnFailover:    func(ev ssdb.FailoverEvent) { events <- ev },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, addr := f.Master(); addr != a.Addr {
+		t.Fatalf("master = %s, want the master a %s", addr, a.Addr)
+	}
+	if err := f.Set("k", "on a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// b is promoted and a goes away.
+	b.SetSlaveOf("")
+	a.Close()
+	select {
+	case ev := <-events:
+		if ev.From != a.Addr || ev.To != b.Addr || ev.Err == nil {
+			t.Fatalf("event = %+v, want a move from a to b", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no failover")
+	}
+	if _, addr := f.Master(); addr != b.Addr {
+		t.Fatalf("master = %s, want b %s", addr, b.Addr)
+	}
+	if err := f.Set("k", "on b"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := f.Get("k"); err != nil || v != "on b" {
+		t.Fatalf("Get = %q, %v, want the value written on b", v, err)
+	}
+}
+
+func TestFailoverDemotion(t *testing.T) {
+	a := newServer(t)
+	b := newServer(t)
+	b.SetSlaveOf(a.Addr)
+	events := make(chan ssdb.FailoverEvent, 4)
+	f, err := ssdb.NewFailoverClient(ssdb.FailoverConfig{
+		Addrs:         []string{a.Addr, b.Addr},
+		ProbeInterval: 20 * time.Millisecond,
+		OnFailover:    func(ev ssdb.FailoverEvent) { events <- ev },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// a becomes a slave of b while staying reachable.
+	b.SetSlaveOf("")
+	a.SetSlaveOf(b.Addr)
+	select {
+	case ev := <-events:
+		if ev.From != a.Addr || ev.To != b.Addr {
+			t.Fatalf("event = %+v, want a move from a to b", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no failover")
+	}
+}
+
+func TestFailoverNoMaster(t *testing.T) {
+	a := newServer(t)
+	a.SetSlaveOf("10.0.0.9:8888")
+	_, err := ssdb.NewFailoverClient(ssdb.FailoverConfig{
+		Addrs:        []string{a.Addr, "127.0.0.1:1"},
+		ProbeTimeout: 200 * time.Millisecond,
+	})
+	if !errors.Is(err, ssdb.ErrNoMaster) {
+		t.Fatalf("NewFailoverClient = %v, want ErrNoMaster", err)
+	}
+}
@@ -0,0 +1,292 @@
+package ssdb
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HashSetStruct stores the fields of the struct v, or of the struct v
+// points to, in hash with multi_hset. The field names are set by ssdb
+// struct tags:
+//
+//	type User struct {
+//		Name    string    `ssdb:"name"`
+//		Age     int       `ssdb:"age,omitempty"`
+//		Created time.Time `ssdb:"created"`
+//		Tags    []string  `ssdb:"tags"`
+//		Secret  string    `ssdb:"-"`
+//	}
+//
+// An exported field without a tag uses its name, "-" skips it and
+// omitempty skips it when it has its zero value. Strings, []byte, ints,
+// floats and bools are stored as text, time.Time and the other
+// encoding.TextMarshaler as their text, and the other fields, e.g. structs,
+// maps and slices, as JSON. Nil pointers are skipped. The fields of an
+// embedded struct without a tag are stored as fields of v. Skipped fields
+// keep their value in hash.
+func (c *Client) HashSetStruct(hash string, v interface{}) error {
+	return c.HashSetStructContext(context.Background(), hash, v)
+}
+
+func (c *Client) HashSetStructContext(ctx context.Context, hash string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("ssdb: HashSetStruct of non-struct %T", v)
+	}
+	params := []interface{}{hash}
+	for _, f := range structFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		val, err := encodeField(fv)
+		if err != nil {
+			return fmt.Errorf("ssdb: field %s: %w", f.name, err)
+		}
+		params = append(params, f.name, val)
+	}
+	if len(params) == 1 {
+		return nil
+	}
+	_, err := c.call(ctx, "multi_hset", params...)
+	return err
+}
+
+// HashGetStruct reads the fields of the struct ptr points to from hash with
+// multi_hget, as stored by HashSetStruct. The fields missing from hash keep
+// their value, ErrNotFound is returned when hash has none of the fields.
+func (c *Client) HashGetStruct(hash string, ptr interface{}) error {
+	return c.HashGetStructContext(context.Background(), hash, ptr)
+}
+
+func (c *Client) HashGetStructContext(ctx context.Context, hash string, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ssdb: HashGetStruct needs a non-nil pointer to a struct, not %T", ptr)
+	}
+	rv = rv.Elem()
+	fields := structFields(rv.Type())
+	params := []interface{}{hash}
+	for _, f := range fields {
+		params = append(params, f.name)
+	}
+	data, err := c.call(ctx, "multi_hget", params...)
+	if err != nil {
+		return err
+	}
+	vals, err := parseMap(data)
+	if err != nil {
+		return err
+	}
+	if len(vals) == 0 {
+		return responseError("multi_hget", []string{StatusNotFound})
+	}
+	for _, f := range fields {
+		val, ok := vals[f.name]
+		if !ok {
+			continue
+		}
+		fv, err := allocByIndex(rv, f.index)
+		if err != nil {
+			return fmt.Errorf("ssdb: field %s: %w", f.name, err)
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if err := decodeField(fv, val); err != nil {
+			return fmt.Errorf("ssdb: field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// structField is a struct field stored in a hash.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the fields of t stored in a hash.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields := appendFields(nil, t, nil)
+	structFieldCache.Store(t, fields)
+	return fields
+}
+
+func appendFields(fields []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("ssdb")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(index[:len(index):len(index)], i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && !hasTag && ft.Kind() == reflect.Struct {
+			fields = appendFields(fields, ft, idx)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		omitEmpty := false
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "omitempty" {
+				omitEmpty = true
+			}
+		}
+		fields = append(fields, structField{name: name, index: idx, omitEmpty: omitEmpty})
+	}
+	return fields
+}
+
+// fieldByIndex returns the field of v at index, false when it is in a nil
+// embedded struct pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// allocByIndex returns the field of v at index, allocating the nil embedded
+// struct pointers on the way. A nil pointer to an unexported embedded struct
+// cannot be set and is an error.
+func allocByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// encodeField returns v as stored in a hash.
+func encodeField(v reflect.Value) (string, error) {
+	if !v.Type().Implements(textMarshalerType) && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		if !v.CanAddr() {
+			// A copy is addressable, e.g. for a struct passed by value.
+			c := reflect.New(v.Type()).Elem()
+			c.Set(v)
+			v = c
+		}
+		v = v.Addr()
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	return string(data), err
+}
+
+// decodeField sets v to the value s stored by encodeField.
+func decodeField(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+	}
+	return json.Unmarshal([]byte(s), v.Addr().Interface())
+}
@@ -0,0 +1,162 @@
+package ssdb_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+)
+
+type Base struct {
+	ID   int64  `ssdb:"id"`
+	Kind string `ssdb:"kind"`
+}
+
+type Meta struct {
+	Source string `ssdb:"source"`
+}
+
+type Address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type record struct {
+	Base
+	*Meta
+	Name     string            `ssdb:"name"`
+	Int8     int8              `ssdb:"i8"`
+	Int      int               `ssdb:"int"`
+	Uint16   uint16            `ssdb:"u16"`
+	Uint64   uint64            `ssdb:"u64"`
+	Float32  float32           `ssdb:"f32"`
+	Float64  float64           `ssdb:"f64"`
+	Active   bool              `ssdb:"active"`
+	Created  time.Time         `ssdb:"created"`
+	Raw      []byte            `ssdb:"raw"`
+	Address  Address           `ssdb:"address"`
+	Tags     []string          `ssdb:"tags"`
+	Labels   map[string]string `ssdb:"labels"`
+	Score    *int              `ssdb:"score"`
+	Note     string            `ssdb:"note,omitempty"`
+	Secret   string            `ssdb:"-"`
+	Untagged string
+	hidden   string
+}
+
+func TestHashStructRoundTrip(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	score := 7
+	in := record{
+		Base:     Base{ID: 42, Kind: "user"},
+		Meta:     &Meta{Source: "import"},
+		Name:     "ann",
+		Int8:     -8,
+		Int:      -1 << 40,
+		Uint16:   65535,
+		Uint64:   math.MaxUint64,
+		Float32:  1.5,
+		Float64:  math.Pi,
+		Active:   true,
+		Created:  time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC),
+		Raw:      []byte{0, 1, '\n', 0xff},
+		Address:  Address{City: "Taipei", Zip: "100"},
+		Tags:     []string{"a", "b"},
+		Labels:   map[string]string{"k": "v"},
+		Score:    &score,
+		Secret:   "s3cret",
+		Untagged: "plain",
+		hidden:   "hidden",
+	}
+	if err := c.HashSetStruct("h", &in); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := c.HashGetAll("h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"id": "42", "kind": "user", "source": "import", "name": "ann",
+		"i8": "-8", "int": "-1099511627776", "u16": "65535", "u64": "18446744073709551615",
+		"f32": "1.5", "f64": "3.141592653589793", "active": "1",
+		"created": "2024-05-06T07:08:09.00000001Z", "raw": "\x00\x01\n\xff",
+		"address": `{"city":"Taipei","zip":"100"}`, "tags": `["a","b"]`, "labels": `{"k":"v"}`,
+		"score": "7", "Untagged": "plain",
+	}
+	if !reflect.DeepEqual(raw, want) {
+		t.Fatalf("hash = %q\nwant %q", raw, want)
+	}
+
+	var out record
+	out.Secret = "kept"
+	if err := c.HashGetStruct("h", &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Secret, in.hidden = "kept", ""
+	if !out.Created.Equal(in.Created) {
+		t.Fatalf("Created = %v, want %v", out.Created, in.Created)
+	}
+	out.Created = in.Created
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("HashGetStruct = %+v\nwant %+v", out, in)
+	}
+}
+
+func TestHashStructOmitEmpty(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if err := c.HashSet("h", "note", "old"); err != nil {
+		t.Fatal(err)
+	}
+	// The empty note and the nil pointers are skipped, the stored note
+	// stays.
+	if err := c.HashSetStruct("h", record{Name: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"score", "source"} {
+		if _, err := c.HashGet("h", field); !errors.Is(err, ssdb.ErrNotFound) {
+			t.Errorf("HashGet(%s) = %v, want the nil field skipped", field, err)
+		}
+	}
+	var out record
+	if err := c.HashGetStruct("h", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Note != "old" || out.Name != "bob" || out.Score != nil || out.Meta != nil {
+		t.Fatalf("HashGetStruct = %+v", out)
+	}
+}
+
+func TestHashStructErrors(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if err := c.HashSetStruct("h", record{Name: "ann"}); err != nil {
+		t.Fatal(err)
+	}
+	var out record
+	var nilPtr *record
+	for _, arg := range []interface{}{out, nilPtr, nil, new(int), "h"} {
+		if err := c.HashGetStruct("h", arg); err == nil {
+			t.Errorf("HashGetStruct(%T) succeeded", arg)
+		}
+	}
+	for _, arg := range []interface{}{nilPtr, nil, 1, []string{"a"}} {
+		if err := c.HashSetStruct("h", arg); err == nil {
+			t.Errorf("HashSetStruct(%T) succeeded", arg)
+		}
+	}
+	if err := c.HashGetStruct("missing", &out); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Errorf("HashGetStruct of a missing hash: %v, want ErrNotFound", err)
+	}
+	if err := c.HashSet("h", "int", "not a number"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.HashGetStruct("h", &out); err == nil {
+		t.Error("HashGetStruct decoded a bad int")
+	}
+}
@@ -0,0 +1,124 @@
+package ssdb
+
+import (
+	"context"
+	"time"
+)
+
+// Cmd is a command seen by a Hook.
+type Cmd struct {
+	// Name is the command name and Args the arguments that follow it. A
+	// hook may change them in BeforeProcess, e.g. to prefix the keys.
+	Name string
+	Args []interface{}
+	// Resp is the response of the command, Status its first element or one
+	// of StatusTimeout, StatusCanceled and StatusConnError when the command
+	// got no response.
+	Resp   []string
+	Status string
+	Err    error
+	// Duration is the time the command took, the time of the whole
+	// pipeline for the commands of a pipeline.
+	Duration time.Duration
+}
+
+func newCmd(args []interface{}) *Cmd {
+	if len(args) == 0 {
+		return &Cmd{}
+	}
+	return &Cmd{Name: cmdName(args), Args: append([]interface{}(nil), args[1:]...)}
+}
+
+// args returns the command name followed by the arguments.
+func (cmd *Cmd) args() []interface{} {
+	return ArrayAppendToFirst([]interface{}{cmd.Name}, cmd.Args)
+}
+
+// done records the result of the command.
+func (cmd *Cmd) done(resp []string, err error, d time.Duration) {
+	cmd.Resp = resp
+	cmd.Err = err
+	cmd.Status = commandStatus(resp, err)
+	cmd.Duration = d
+}
+
+// Hook is called around the commands of a client, add it with AddHook or
+// WithHooks. Client.Do, ProcessCmd and the helpers call BeforeProcess and
+// AfterProcess, Pipeline.Exec, MultiMode and BatchSend call the pipeline
+// variants once for all their commands. Send and Recv are not hooked.
+//
+// The BeforeProcess of the hooks run in the order they were added and the
+// AfterProcess in the reverse order. An error returned by BeforeProcess
+// cancels the command and the following hooks, an error returned by
+// AfterProcess replaces the error of the command.
+type Hook interface {
+	BeforeProcess(ctx context.Context, cmd *Cmd) (context.Context, error)
+	AfterProcess(ctx context.Context, cmd *Cmd) error
+	BeforeProcessPipeline(ctx context.Context, cmds []*Cmd) (context.Context, error)
+	AfterProcessPipeline(ctx context.Context, cmds []*Cmd) error
+}
+
+// AddHook adds h to the hooks of the client, connections borrowed by
+// MultiHashSet and BatchSend get the hooks of the client.
+func (c *Client) AddHook(h Hook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], h)
+}
+
+func (c *Client) getHooks() hooks {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hooks
+}
+
+type hooks []Hook
+
+// process runs fn on cmd between the hooks. The AfterProcess of a hook gets
+// the context returned by its BeforeProcess.
+func (hs hooks) process(ctx context.Context, cmd *Cmd, fn func(context.Context, *Cmd)) error {
+	ctxs := make([]context.Context, 0, len(hs))
+	for _, h := range hs {
+		hctx, err := h.BeforeProcess(ctx, cmd)
+		if err != nil {
+			cmd.Err = err
+			break
+		}
+		ctx = hctx
+		ctxs = append(ctxs, ctx)
+	}
+	if cmd.Err == nil {
+		fn(ctx, cmd)
+	}
+	for i := len(ctxs) - 1; i >= 0; i-- {
+		if err := hs[i].AfterProcess(ctxs[i], cmd); err != nil {
+			cmd.Err = err
+		}
+	}
+	return cmd.Err
+}
+
+// processPipeline runs fn on cmds between the pipeline hooks, fn returns
+// the error of the pipeline.
+func (hs hooks) processPipeline(ctx context.Context, cmds []*Cmd, fn func(context.Context, []*Cmd) error) error {
+	var err error
+	ctxs := make([]context.Context, 0, len(hs))
+	for _, h := range hs {
+		hctx, herr := h.BeforeProcessPipeline(ctx, cmds)
+		if herr != nil {
+			err = herr
+			break
+		}
+		ctx = hctx
+		ctxs = append(ctxs, ctx)
+	}
+	if err == nil {
+		err = fn(ctx, cmds)
+	}
+	for i := len(ctxs) - 1; i >= 0; i-- {
+		if herr := hs[i].AfterProcessPipeline(ctxs[i], cmds); herr != nil {
+			err = herr
+		}
+	}
+	return err
+}
@@ -0,0 +1,237 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+)
+
+type hookKey string
+
+// logHook records its calls in log and puts its name in the context.
+type logHook struct {
+	name      string
+	log       *[]string
+	beforeErr error
+	afterErr  error
+	// ctxs are the values of the context seen by the After calls.
+	ctxs []interface{}
+}
+
+func (h *logHook) BeforeProcess(ctx context.Context, cmd *ssdb.Cmd) (context.Context, error) {
+	*h.log = append(*h.log, h.name+" before "+cmd.Name)
+	if h.beforeErr != nil {
+		return ctx, h.beforeErr
+	}
+	return context.WithValue(ctx, hookKey(h.name), h.name), nil
+}
+
+func (h *logHook) AfterProcess(ctx context.Context, cmd *ssdb.Cmd) error {
+	*h.log = append(*h.log, h.name+" after "+cmd.Name+" "+cmd.Status)
+	h.ctxs = append(h.ctxs, ctx.Value(hookKey(h.name)))
+	return h.afterErr
+}
+
+func (h *logHook) BeforeProcessPipeline(ctx context.Context, cmds []*ssdb.Cmd) (context.Context, error) {
+	*h.log = append(*h.log, fmt.Sprintf("%s before pipeline %d", h.name, len(cmds)))
+	if h.beforeErr != nil {
+		return ctx, h.beforeErr
+	}
+	return context.WithValue(ctx, hookKey(h.name), h.name), nil
+}
+
+func (h *logHook) AfterProcessPipeline(ctx context.Context, cmds []*ssdb.Cmd) error {
+	ok := 0
+	for _, cmd := range cmds {
+		if cmd.Status == ssdb.StatusOK {
+			ok++
+		}
+	}
+	*h.log = append(*h.log, fmt.Sprintf("%s after pipeline %d ok", h.name, ok))
+	h.ctxs = append(h.ctxs, ctx.Value(hookKey(h.name)))
+	return h.afterErr
+}
+
+func checkLog(t *testing.T, log *[]string, want ...string) {
+	t.Helper()
+	if !reflect.DeepEqual(*log, want) {
+		t.Fatalf("hooks called\n%q\nwant\n%q", *log, want)
+	}
+	*log = nil
+}
+
+func TestHookOrder(t *testing.T) {
+	srv := newServer(t)
+	var log []string
+	a := &logHook{name: "a", log: &log}
+	b := &logHook{name: "b", log: &log}
+	c := dial(t, srv, ssdb.WithHooks(a))
+	c.AddHook(b)
+
+	if err := c.Set("k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, &log, "a before set", "b before set", "b after set ok", "a after set ok")
+	if _, err := c.Get("missing"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatal(err)
+	}
+	checkLog(t, &log, "a before get", "b before get", "b after get not_found", "a after get not_found")
+
+	// Send and Recv are not hooked.
+	if err := c.Send([]interface{}{"get", "k"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, &log)
+}
+
+func TestHookContext(t *testing.T) {
+	srv := newServer(t)
+	var log []string
+	a := &logHook{name: "a", log: &log}
+	var seen []interface{}
+	b := hookFuncs{before: func(ctx context.Context, cmd *ssdb.Cmd) (context.Context, error) {
+		seen = append(seen, ctx.Value(hookKey("a")))
+		return ctx, nil
+	}}
+	c := dial(t, srv, ssdb.WithHooks(a, b))
+	if err := c.Set("k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seen, []interface{}{"a"}) {
+		t.Fatalf("the next hook saw %v, want the context of a", seen)
+	}
+	if !reflect.DeepEqual(a.ctxs, []interface{}{"a"}) {
+		t.Fatalf("AfterProcess saw %v, want the context of BeforeProcess", a.ctxs)
+	}
+
+	// The command runs with the context of the hooks.
+	cancelled := hookFuncs{before: func(ctx context.Context, cmd *ssdb.Cmd) (context.Context, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		return ctx, nil
+	}}
+	c = dial(t, srv, ssdb.WithHooks(cancelled))
+	if _, err := c.Get("k"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get with a cancelled hook context: %v", err)
+	}
+
+	// BeforeProcess may rewrite the command.
+	prefix := hookFuncs{before: func(ctx context.Context, cmd *ssdb.Cmd) (context.Context, error) {
+		cmd.Args[0] = "p:" + cmd.Args[0].(string)
+		return ctx, nil
+	}}
+	c = dial(t, srv, ssdb.WithHooks(prefix))
+	if err := c.Set("k", "prefixed"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := dial(t, srv).Get("p:k"); err != nil || v != "prefixed" {
+		t.Fatalf("Get(p:k) = %q, %v", v, err)
+	}
+}
+
+func TestHookPipeline(t *testing.T) {
+	srv := newServer(t)
+	var log []string
+	a := &logHook{name: "a", log: &log}
+	b := &logHook{name: "b", log: &log}
+	c := dial(t, srv, ssdb.WithHooks(a, b))
+
+	p := c.Pipeline()
+	p.Append("set", "x", "1")
+	p.Append("get", "x")
+	p.Append("get", "missing")
+	if _, err := p.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, &log, "a before pipeline 3", "b before pipeline 3", "b after pipeline 2 ok", "a after pipeline 2 ok")
+
+	if err := c.BatchSend([][]interface{}{{"set", "y", "1"}, {"set", "z", "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, &log, "a before pipeline 2", "b before pipeline 2", "b after pipeline 2 ok", "a after pipeline 2 ok")
+	if v, err := c.Get("z"); err != nil || v != "2" {
+		t.Fatalf("Get(z) = %q, %v, want the value of BatchSend", v, err)
+	}
+	log = nil
+
+	if _, err := c.MultiMode([][]interface{}{{"get", "x"}}); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, &log, "a before pipeline 1", "b before pipeline 1", "b after pipeline 1 ok", "a after pipeline 1 ok")
+	for _, v := range a.ctxs {
+		if v != "a" {
+			t.Fatalf("AfterProcessPipeline saw %v, want the context of BeforeProcessPipeline", a.ctxs)
+		}
+	}
+}
+
+func TestHookError(t *testing.T) {
+	srv := newServer(t)
+	var log []string
+	errRefused := errors.New("refused by hook")
+	a := &logHook{name: "a", log: &log}
+	b := &logHook{name: "b", log: &log, beforeErr: errRefused}
+	d := &logHook{name: "d", log: &log}
+	c := dial(t, srv, ssdb.WithHooks(a, b, d))
+
+	// The command and the following hooks are skipped, the hooks that ran
+	// get their AfterProcess.
+	if err := c.Set("k", "v"); !errors.Is(err, errRefused) {
+		t.Fatalf("Set = %v, want the error of the hook", err)
+	}
+	checkLog(t, &log, "a before set", "b before set", "a after set ")
+	if _, err := dial(t, srv).Get("k"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("the refused command reached the server: %v", err)
+	}
+
+	p := c.Pipeline()
+	p.Append("set", "k", "v")
+	if _, err := p.Exec(); !errors.Is(err, errRefused) {
+		t.Fatalf("Exec = %v, want the error of the hook", err)
+	}
+	checkLog(t, &log, "a before pipeline 1", "b before pipeline 1", "a after pipeline 0 ok")
+	if err := c.BatchSend([][]interface{}{{"set", "k", "v"}}); !errors.Is(err, errRefused) {
+		t.Fatalf("BatchSend = %v, want the error of the hook", err)
+	}
+	log = nil
+	if _, err := dial(t, srv).Get("k"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("the refused pipeline reached the server: %v", err)
+	}
+
+	// An AfterProcess error replaces the error of the command.
+	errAfter := errors.New("after")
+	c = dial(t, srv, ssdb.WithHooks(&logHook{name: "e", log: &log, afterErr: errAfter}))
+	if err := c.Set("k", "v"); !errors.Is(err, errAfter) {
+		t.Fatalf("Set = %v, want the error of AfterProcess", err)
+	}
+	if v, err := dial(t, srv).Get("k"); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v, want the command run", v, err)
+	}
+}
+
+// hookFuncs is a Hook made of functions, a nil function does nothing.
+type hookFuncs struct {
+	before func(context.Context, *ssdb.Cmd) (context.Context, error)
+}
+
+func (h hookFuncs) BeforeProcess(ctx context.Context, cmd *ssdb.Cmd) (context.Context, error) {
+	if h.before == nil {
+		return ctx, nil
+	}
+	return h.before(ctx, cmd)
+}
+
+func (hookFuncs) AfterProcess(context.Context, *ssdb.Cmd) error { return nil }
+
+func (hookFuncs) BeforeProcessPipeline(ctx context.Context, _ []*ssdb.Cmd) (context.Context, error) {
+	return ctx, nil
+}
+
+func (hookFuncs) AfterProcessPipeline(context.Context, []*ssdb.Cmd) error { return nil }
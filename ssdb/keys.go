@@ -0,0 +1,116 @@
+package ssdb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyArgs tells which arguments of a command are keys or names.
+type keyArgs int
+
+const (
+	keyNone  keyArgs = iota // no key
+	keyFirst                // the first argument
+	keyAll                  // all arguments
+	keyPairs                // the first argument of each key/value pair
+	keyRange                // the first two arguments bound a key range
+)
+
+// keyRule tells where the keys of a command are, for namespaces and
+// sharding. When list is set the response lists keys, or key/value pairs when
+// pairs is set, in key order, in reverse order when reverse is set.
+type keyRule struct {
+	args    keyArgs
+	reverse bool
+	list    bool
+	pairs   bool
+}
+
+var (
+	ruleKey   = keyRule{args: keyFirst}
+	ruleList  = keyRule{args: keyRange, list: true}
+	ruleRList = keyRule{args: keyRange, reverse: true, list: true}
+)
+
+// commandKeys holds the commands whose keys are known, the other commands
+// are refused by namespaces and sharded clients.
+var commandKeys = map[string]keyRule{
+	"ping":   {},
+	"info":   {},
+	"dbsize": {},
+	"auth":   {},
+
+	"get": ruleKey, "set": ruleKey, "setx": ruleKey, "setnx": ruleKey, "getset": ruleKey,
+	"del": ruleKey, "incr": ruleKey, "exists": ruleKey, "expire": ruleKey, "ttl": ruleKey,
+	"multi_set": {args: keyPairs}, "multi_get": {args: keyAll, list: true, pairs: true}, "multi_del": {args: keyAll},
+	"scan":  {args: keyRange, list: true, pairs: true},
+	"rscan": {args: keyRange, reverse: true, list: true, pairs: true},
+	"keys":  ruleList,
+	"rkeys": ruleRList,
+
+	"hset": ruleKey, "hget": ruleKey, "hdel": ruleKey, "hincr": ruleKey, "hexists": ruleKey,
+	"hsize": ruleKey, "hkeys": ruleKey, "hrkeys": ruleKey, "hgetall": ruleKey, "hscan": ruleKey,
+	"hrscan": ruleKey, "hclear": ruleKey, "multi_hset": ruleKey, "multi_hget": ruleKey,
+	"multi_hdel": ruleKey,
+	"hlist":      ruleList,
+	"hrlist":     ruleRList,
+
+	"zset": ruleKey, "zget": ruleKey, "zdel": ruleKey, "zincr": ruleKey, "zexists": ruleKey,
+	"zsize": ruleKey, "zrank": ruleKey, "zrrank": ruleKey, "zrange": ruleKey, "zrrange": ruleKey,
+	"zscan": ruleKey, "zrscan": ruleKey, "zkeys": ruleKey, "zcount": ruleKey, "zsum": ruleKey,
+	"zavg": ruleKey, "zremrangebyrank": ruleKey, "zremrangebyscore": ruleKey,
+	"zpop_front": ruleKey, "zpop_back": ruleKey, "zclear": ruleKey, "multi_zset": ruleKey,
+	"multi_zget": ruleKey, "multi_zdel": ruleKey,
+	"zlist":  ruleList,
+	"zrlist": ruleRList,
+
+	"qpush_front": ruleKey, "qpush_back": ruleKey, "qpush": ruleKey, "qpop_front": ruleKey,
+	"qpop_back": ruleKey, "qpop": ruleKey, "qfront": ruleKey, "qback": ruleKey, "qsize": ruleKey,
+	"qget": ruleKey, "qset": ruleKey, "qrange": ruleKey, "qslice": ruleKey,
+	"qtrim_front": ruleKey, "qtrim_back": ruleKey, "qclear": ruleKey,
+	"qlist":  ruleList,
+	"qrlist": ruleRList,
+}
+
+// KeyIndexes returns the indexes in args of the keys and names of the
+// command cmd, args not including the command name. ok is false when the
+// keys of cmd are not known.
+func KeyIndexes(cmd string, args []interface{}) (indexes []int, ok bool) {
+	rule, ok := commandKeys[strings.ToLower(cmd)]
+	if !ok {
+		return nil, false
+	}
+	switch rule.args {
+	case keyFirst:
+		indexes = []int{0}
+	case keyAll:
+		for i := range args {
+			indexes = append(indexes, i)
+		}
+	case keyPairs:
+		for i := 0; i < len(args); i += 2 {
+			indexes = append(indexes, i)
+		}
+	case keyRange:
+		indexes = []int{0, 1}
+	}
+	for len(indexes) > 0 && indexes[len(indexes)-1] >= len(args) {
+		indexes = indexes[:len(indexes)-1]
+	}
+	return indexes, true
+}
+
+// argString returns an argument as it is sent to the server.
+func argString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return fmt.Sprintf("%f", v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(arg)
+}
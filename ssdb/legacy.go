@@ -0,0 +1,206 @@
+package ssdb
+
+// LegacyClient keeps the helpers of previous versions that return
+// interface{} results as converted by ProcessCmd.
+//
+// Deprecated: use the typed helpers of Client.
+type LegacyClient struct {
+	*Client
+}
+
+// Legacy returns a view of c with the untyped helpers of previous versions.
+//
+// Deprecated: use the typed helpers of Client.
+func (c *Client) Legacy() *LegacyClient {
+	return &LegacyClient{Client: c}
+}
+
+// Auth is the untyped form of Client.Auth.
+//
+// Deprecated: use Client.Auth.
+func (c *LegacyClient) Auth(pwd string) (interface{}, error) {
+	return c.Do("auth", pwd)
+}
+
+// Set is the untyped form of Client.Set.
+//
+// Deprecated: use Client.Set.
+func (c *LegacyClient) Set(key string, val string) (interface{}, error) {
+	params := []interface{}{key, val}
+	return c.ProcessCmd("set", params)
+}
+
+// Get is the untyped form of Client.Get.
+//
+// Deprecated: use Client.Get.
+func (c *LegacyClient) Get(key string) (interface{}, error) {
+	params := []interface{}{key}
+	return c.ProcessCmd("get", params)
+}
+
+// Del is the untyped form of Client.Del.
+//
+// Deprecated: use Client.Del.
+func (c *LegacyClient) Del(key string) (interface{}, error) {
+	params := []interface{}{key}
+	return c.ProcessCmd("del", params)
+}
+
+// SetX is the untyped form of Client.SetX.
+//
+// Deprecated: use Client.SetX.
+func (c *LegacyClient) SetX(key string, val string, ttl int) (interface{}, error) {
+	params := []interface{}{key, val, ttl}
+	return c.ProcessCmd("setx", params)
+}
+
+// Scan is the untyped form of Client.Scan.
+//
+// Deprecated: use Client.Scan.
+func (c *LegacyClient) Scan(start string, end string, limit int) (interface{}, error) {
+	params := []interface{}{start, end, limit}
+	return c.ProcessCmd("scan", params)
+}
+
+// Expire is the untyped form of Client.Expire.
+//
+// Deprecated: use Client.Expire.
+func (c *LegacyClient) Expire(key string, ttl int) (interface{}, error) {
+	params := []interface{}{key, ttl}
+	return c.ProcessCmd("expire", params)
+}
+
+// KeyTTL is the untyped form of Client.KeyTTL.
+//
+// Deprecated: use Client.KeyTTL.
+func (c *LegacyClient) KeyTTL(key string) (interface{}, error) {
+	params := []interface{}{key}
+	return c.ProcessCmd("ttl", params)
+}
+
+// SetNew is the untyped form of Client.SetNew.
+//
+// Deprecated: use Client.SetNew.
+func (c *LegacyClient) SetNew(key string, val string) (interface{}, error) {
+	params := []interface{}{key, val}
+	return c.ProcessCmd("setnx", params)
+}
+
+// GetSet is the untyped form of Client.GetSet.
+//
+// Deprecated: use Client.GetSet.
+func (c *LegacyClient) GetSet(key string, val string) (interface{}, error) {
+	params := []interface{}{key, val}
+	return c.ProcessCmd("getset", params)
+}
+
+// Incr is the untyped form of Client.Incr.
+//
+// Deprecated: use Client.Incr.
+func (c *LegacyClient) Incr(key string, val int) (interface{}, error) {
+	params := []interface{}{key, val}
+	return c.ProcessCmd("incr", params)
+}
+
+// Exists is the untyped form of Client.Exists.
+//
+// Deprecated: use Client.Exists.
+func (c *LegacyClient) Exists(key string) (interface{}, error) {
+	params := []interface{}{key}
+	return c.ProcessCmd("exists", params)
+}
+
+// HashSet is the untyped form of Client.HashSet.
+//
+// Deprecated: use Client.HashSet.
+func (c *LegacyClient) HashSet(hash string, key string, val string) (interface{}, error) {
+	params := []interface{}{hash, key, val}
+	return c.ProcessCmd("hset", params)
+}
+
+// HashGet is the untyped form of Client.HashGet.
+//
+// Deprecated: use Client.HashGet.
+func (c *LegacyClient) HashGet(hash string, key string) (interface{}, error) {
+	params := []interface{}{hash, key}
+	return c.ProcessCmd("hget", params)
+}
+
+// HashDel is the untyped form of Client.HashDel.
+//
+// Deprecated: use Client.HashDel.
+func (c *LegacyClient) HashDel(hash string, key string) (interface{}, error) {
+	params := []interface{}{hash, key}
+	return c.ProcessCmd("hdel", params)
+}
+
+// HashIncr is the untyped form of Client.HashIncr.
+//
+// Deprecated: use Client.HashIncr.
+func (c *LegacyClient) HashIncr(hash string, key string, val int) (interface{}, error) {
+	params := []interface{}{hash, key, val}
+	return c.ProcessCmd("hincr", params)
+}
+
+// HashExists is the untyped form of Client.HashExists.
+//
+// Deprecated: use Client.HashExists.
+func (c *LegacyClient) HashExists(hash string, key string) (interface{}, error) {
+	params := []interface{}{hash, key}
+	return c.ProcessCmd("hexists", params)
+}
+
+// HashSize is the untyped form of Client.HashSize.
+//
+// Deprecated: use Client.HashSize.
+func (c *LegacyClient) HashSize(hash string) (interface{}, error) {
+	params := []interface{}{hash}
+	return c.ProcessCmd("hsize", params)
+}
+
+// HashList is the untyped form of Client.HashList.
+//
+// Deprecated: use Client.HashList.
+func (c *LegacyClient) HashList(start string, end string, limit int) (interface{}, error) {
+	params := []interface{}{start, end, limit}
+	return c.ProcessCmd("hlist", params)
+}
+
+// HashKeys is the untyped form of Client.HashKeys.
+//
+// Deprecated: use Client.HashKeys.
+func (c *LegacyClient) HashKeys(hash string, start string, end string, limit int) (interface{}, error) {
+	params := []interface{}{hash, start, end, limit}
+	return c.ProcessCmd("hkeys", params)
+}
+
+// HashMultiSet is the untyped form of Client.HashMultiSet.
+//
+// Deprecated: use Client.HashMultiSet.
+func (c *LegacyClient) HashMultiSet(hash string, data map[string]string) (interface{}, error) {
+	params := []interface{}{hash}
+	for k, v := range data {
+		params = append(params, k)
+		params = append(params, v)
+	}
+	return c.ProcessCmd("multi_hset", params)
+}
+
+// HashMultiDel is the untyped form of Client.HashMultiDel.
+//
+// Deprecated: use Client.HashMultiDel.
+func (c *LegacyClient) HashMultiDel(hash string, keys []string) (interface{}, error) {
+	params := []interface{}{hash}
+	for _, v := range keys {
+		params = append(params, v)
+	}
+	return c.ProcessCmd("multi_hdel", params)
+}
+
+// HashClear is the untyped form of Client.HashClear.
+//
+// Deprecated: use Client.HashClear.
+func (c *LegacyClient) HashClear(hash string) (interface{}, error) {
+	params := []interface{}{hash}
+	return c.ProcessCmd("hclear", params)
+}
@@ -0,0 +1,87 @@
+package ssdb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// TestLegacyShapes checks that the untyped helpers keep the results of
+// previous versions: a bool for the flags, a string for the other single
+// values, a map for the scans and the raw response for Auth. The calls run
+// in order on one server.
+func TestLegacyShapes(t *testing.T) {
+	srv := newServer(t)
+	srv.SetPassword("pw")
+	l := dial(t, srv, ssdb.WithPassword("pw")).Legacy()
+	for _, tt := range []struct {
+		name string
+		call func() (interface{}, error)
+		want interface{}
+	}{
+		{"Auth", func() (interface{}, error) { return l.Auth("pw") }, []string{"ok", "1"}},
+		{"Set", func() (interface{}, error) { return l.Set("k", "v") }, true},
+		{"Get", func() (interface{}, error) { return l.Get("k") }, "v"},
+		{"GetSet", func() (interface{}, error) { return l.GetSet("k", "w") }, "v"},
+		{"SetNew existing", func() (interface{}, error) { return l.SetNew("k", "x") }, false},
+		{"SetNew", func() (interface{}, error) { return l.SetNew("n", "1") }, true},
+		{"Incr", func() (interface{}, error) { return l.Incr("n", 10) }, "11"},
+		{"Exists", func() (interface{}, error) { return l.Exists("k") }, true},
+		{"Exists missing", func() (interface{}, error) { return l.Exists("missing") }, false},
+		{"Expire", func() (interface{}, error) { return l.Expire("k", 100) }, true},
+		{"Expire missing", func() (interface{}, error) { return l.Expire("missing", 100) }, false},
+		{"KeyTTL", func() (interface{}, error) { return l.KeyTTL("k") }, "100"},
+		{"SetX", func() (interface{}, error) { return l.SetX("x", "1", 10) }, "1"},
+		{"Scan", func() (interface{}, error) { return l.Scan("", "", 10) }, map[string]string{"k": "w", "n": "11", "x": "1"}},
+		{"Del", func() (interface{}, error) { return l.Del("k") }, true},
+		{"HashSet", func() (interface{}, error) { return l.HashSet("h", "a", "1") }, "1"},
+		{"HashGet", func() (interface{}, error) { return l.HashGet("h", "a") }, "1"},
+		{"HashIncr", func() (interface{}, error) { return l.HashIncr("h", "a", 2) }, "3"},
+		{"HashExists", func() (interface{}, error) { return l.HashExists("h", "a") }, true},
+		{"HashExists missing", func() (interface{}, error) { return l.HashExists("h", "missing") }, false},
+		{"HashMultiSet", func() (interface{}, error) { return l.HashMultiSet("h", map[string]string{"b": "2", "c": "3"}) }, "2"},
+		{"HashSize", func() (interface{}, error) { return l.HashSize("h") }, int64(3)},
+		{"HashKeys", func() (interface{}, error) { return l.HashKeys("h", "", "", 10) }, []string{"a", "b", "c"}},
+		{"HashList", func() (interface{}, error) { return l.HashList("", "", 10) }, "h"},
+		{"HashMultiDel", func() (interface{}, error) { return l.HashMultiDel("h", []string{"a", "b"}) }, "2"},
+		{"HashDel", func() (interface{}, error) { return l.HashDel("h", "c") }, "1"},
+		{"HashClear", func() (interface{}, error) { return l.HashClear("h") }, "0"},
+	} {
+		got, err := tt.call()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLegacyErrors(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	l := c.Legacy()
+	for name, call := range map[string]func() (interface{}, error){
+		"Get":     func() (interface{}, error) { return l.Get("missing") },
+		"HashGet": func() (interface{}, error) { return l.HashGet("h", "missing") },
+	} {
+		got, err := call()
+		if got != nil || !errors.Is(err, ssdb.ErrNotFound) {
+			t.Errorf("%s of a missing key = %#v, %v, want nil and ErrNotFound", name, got, err)
+		}
+	}
+
+	srv.AddFault("incr", ssdbtest.Fault{Response: []string{"error", "value is not an integer"}, Times: 1})
+	var e *ssdb.Error
+	if got, err := l.Incr("n", 1); got != nil || !errors.As(err, &e) || e.Status != ssdb.StatusError {
+		t.Fatalf("Incr with an error response = %#v, %v", got, err)
+	}
+
+	c.Close()
+	if got, err := l.Set("k", "v"); got != nil || !errors.Is(err, ssdb.ErrClosed) {
+		t.Fatalf("Set on a closed client = %#v, %v, want ErrClosed", got, err)
+	}
+}
@@ -0,0 +1,91 @@
+package ssdb
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger receives the log messages of a client. The methods take a message
+// followed by key-value pairs as in log/slog, a *slog.Logger is a Logger:
+//
+//	c, err := ssdb.Dial(addr, ssdb.WithLogger(slog.Default()))
+//
+// A client without a logger logs nothing, except the debug messages written
+// to the standard logger in debug mode. Use NewStdLogger(nil) to get the
+// messages on the standard logger.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NewStdLogger returns a Logger writing every level to l, or to the standard
+// logger when l is nil.
+func NewStdLogger(l *log.Logger) Logger {
+	return stdLogger{l}
+}
+
+// defaultLogger is the logger of a client without one, it discards the
+// messages. debugLogger writes the debug messages of such a client in debug
+// mode, see Client.Debug.
+var (
+	defaultLogger Logger = discardLogger{}
+	debugLogger   Logger = stdLogger{}
+)
+
+type discardLogger struct{}
+
+func (discardLogger) Debug(msg string, args ...any) {}
+func (discardLogger) Info(msg string, args ...any)  {}
+func (discardLogger) Warn(msg string, args ...any)  {}
+func (discardLogger) Error(msg string, args ...any) {}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s stdLogger) Debug(msg string, args ...any) { s.output("DEBUG", msg, args) }
+func (s stdLogger) Info(msg string, args ...any)  { s.output("INFO", msg, args) }
+func (s stdLogger) Warn(msg string, args ...any)  { s.output("WARN", msg, args) }
+func (s stdLogger) Error(msg string, args ...any) { s.output("ERROR", msg, args) }
+
+// output writes "level msg key=value ..." to the logger.
+func (s stdLogger) output(level, msg string, args []any) {
+	var b strings.Builder
+	b.WriteString("ssdb: ")
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%v", args[i])
+			break
+		}
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	if s.l != nil {
+		s.l.Output(3, b.String())
+	} else {
+		log.Output(3, b.String())
+	}
+}
+
+// logger returns the logger of the client.
+func (c *Client) logger() Logger {
+	if c.opts.logger != nil {
+		return c.opts.logger
+	}
+	return defaultLogger
+}
+
+// debugLog logs a debug message. Without a logger set by WithLogger the
+// message is only written in debug mode.
+func (c *Client) debugLog(msg string, args ...any) {
+	if c.opts.logger != nil {
+		c.opts.logger.Debug(msg, args...)
+	} else if c.debug.Load() {
+		debugLogger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,268 @@
+package ssdb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Statuses reported to Metrics for commands that got no response.
+const (
+	StatusTimeout   = "timeout"
+	StatusCanceled  = "canceled"
+	StatusConnError = "conn_error"
+)
+
+// Metrics receives the measurements of a client, set it with WithMetrics.
+// The methods are called by the goroutines running commands and must be safe
+// for concurrent use.
+type Metrics interface {
+	// ObserveCommand is called when a command completed. status is the
+	// status of the response, or StatusTimeout, StatusCanceled or
+	// StatusConnError when the command got no response.
+	ObserveCommand(cmd string, status string, d time.Duration)
+	// ObserveReconnect is called on every reconnect event of the client.
+	ObserveReconnect(ev ReconnectEvent)
+	// ObserveBytesSent and ObserveBytesReceived are called with the number
+	// of bytes written to and read from the connection.
+	ObserveBytesSent(n int)
+	ObserveBytesReceived(n int)
+	// ObserveZip is called for each packet sent or received in the zip
+	// envelope with its size before and after compression.
+	ObserveZip(raw, zipped int)
+}
+
+// DefaultLatencyBuckets are the upper bounds of the latency histograms of a
+// MetricsCollector.
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// MetricsCollector is a Metrics keeping counters and per-command latency
+// histograms in memory, read them with Snapshot. A collector can be shared
+// by several clients.
+type MetricsCollector struct {
+	buckets []time.Duration
+	mu      sync.Mutex
+	stats   MetricsSnapshot
+}
+
+// MetricsSnapshot holds the values of a MetricsCollector.
+type MetricsSnapshot struct {
+	// Commands is the number of completed commands.
+	Commands uint64
+	// Errors counts the commands by status, for every status other than ok.
+	Errors map[string]uint64
+	// Timeouts is the number of commands that timed out, also counted in
+	// Errors.
+	Timeouts uint64
+	// Reconnects is the number of successful reconnects, ReconnectAttempts
+	// the number of failed attempts.
+	Reconnects        uint64
+	ReconnectAttempts uint64
+	BytesSent         uint64
+	BytesReceived     uint64
+	// ZipRaw and ZipCompressed are the sizes of the zipped packets before
+	// and after compression.
+	ZipRaw        uint64
+	ZipCompressed uint64
+	// Latency holds a histogram per command name.
+	Latency map[string]Histogram
+}
+
+// ZipRatio returns the compressed size of the zipped packets divided by
+// their raw size, 0 when nothing was zipped.
+func (s MetricsSnapshot) ZipRatio() float64 {
+	if s.ZipRaw == 0 {
+		return 0
+	}
+	return float64(s.ZipCompressed) / float64(s.ZipRaw)
+}
+
+// Histogram is a latency distribution. Counts[i] is the number of values at
+// most Buckets[i] and above the previous bucket, the last count holds the
+// values above the last bucket.
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// NewMetricsCollector returns a collector with DefaultLatencyBuckets.
+func NewMetricsCollector() *MetricsCollector {
+	return NewMetricsCollectorBuckets(DefaultLatencyBuckets)
+}
+
+// NewMetricsCollectorBuckets returns a collector whose latency histograms
+// have the given upper bounds.
+func NewMetricsCollectorBuckets(buckets []time.Duration) *MetricsCollector {
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	m := &MetricsCollector{buckets: buckets}
+	m.Reset()
+	return m
+}
+
+func (m *MetricsCollector) ObserveCommand(cmd string, status string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Commands++
+	if status != StatusOK {
+		m.stats.Errors[status]++
+	}
+	if status == StatusTimeout {
+		m.stats.Timeouts++
+	}
+	h, ok := m.stats.Latency[cmd]
+	if !ok {
+		h = Histogram{Buckets: m.buckets, Counts: make([]uint64, len(m.buckets)+1)}
+	}
+	i := sort.Search(len(m.buckets), func(i int) bool { return d <= m.buckets[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+	m.stats.Latency[cmd] = h
+}
+
+func (m *MetricsCollector) ObserveReconnect(ev ReconnectEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case ev.State == StateConnected:
+		m.stats.Reconnects++
+	case ev.Err != nil:
+		m.stats.ReconnectAttempts++
+	}
+}
+
+func (m *MetricsCollector) ObserveBytesSent(n int) {
+	m.mu.Lock()
+	m.stats.BytesSent += uint64(n)
+	m.mu.Unlock()
+}
+
+func (m *MetricsCollector) ObserveBytesReceived(n int) {
+	m.mu.Lock()
+	m.stats.BytesReceived += uint64(n)
+	m.mu.Unlock()
+}
+
+func (m *MetricsCollector) ObserveZip(raw, zipped int) {
+	m.mu.Lock()
+	m.stats.ZipRaw += uint64(raw)
+	m.stats.ZipCompressed += uint64(zipped)
+	m.mu.Unlock()
+}
+
+// Snapshot returns a copy of the values of the collector.
+func (m *MetricsCollector) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats
+	s.Errors = make(map[string]uint64, len(m.stats.Errors))
+	for status, n := range m.stats.Errors {
+		s.Errors[status] = n
+	}
+	s.Latency = make(map[string]Histogram, len(m.stats.Latency))
+	for cmd, h := range m.stats.Latency {
+		h.Counts = append([]uint64(nil), h.Counts...)
+		s.Latency[cmd] = h
+	}
+	return s
+}
+
+// Reset sets all values of the collector to zero.
+func (m *MetricsCollector) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = MetricsSnapshot{
+		Errors:  make(map[string]uint64),
+		Latency: make(map[string]Histogram),
+	}
+}
+
+// commandStatus returns the status of a command for Metrics.
+func commandStatus(resp []string, err error) string {
+	if err == nil {
+		if len(resp) == 0 {
+			return StatusError
+		}
+		return resp[0]
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Status != "" {
+		return e.Status
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return StatusTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return StatusCanceled
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return StatusTimeout
+	}
+	return StatusConnError
+}
+
+// observe reports a completed request to the metrics of the client.
+func (c *Client) observe(req *request, resp []string, err error) {
+	if m := c.opts.metrics; m != nil {
+		m.ObserveCommand(cmdName(req.args), commandStatus(resp, err), time.Since(req.start))
+	}
+}
+
+// packetSize returns the size of resp in the wire format.
+func packetSize(resp []string) int {
+	n := 1
+	for _, block := range resp {
+		n += len(strconv.Itoa(len(block))) + len(block) + 2
+	}
+	return n
+}
+
+// zipPacketSize returns the size in the wire format of a zip packet whose
+// compressed data has n bytes.
+func zipPacketSize(n int) int {
+	return len("3\nzip\n") + len(strconv.Itoa(n)) + n + 3
+}
+
+// countReader counts the bytes read from a connection.
+type countReader struct {
+	r net.Conn
+	n int
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+	return n, err
+}
+
+// take returns the bytes read since the last call.
+func (cr *countReader) take() int {
+	n := cr.n
+	cr.n = 0
+	return n
+}
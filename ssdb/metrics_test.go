@@ -0,0 +1,130 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	srv := newServer(t)
+	m := ssdb.NewMetricsCollector()
+	c := dial(t, srv, ssdb.WithMetrics(m), ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}))
+
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("missing"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatal(err)
+	}
+	srv.AddFault("incr", ssdbtest.Fault{Response: []string{"error", "server busy"}, Times: 1})
+	c.Incr("n", 1)
+	srv.AddFault("get", ssdbtest.Fault{Latency: 200 * time.Millisecond, Times: 1})
+	if _, err := c.Do(20, "get", "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do past its timeout: %v", err)
+	}
+
+	s := m.Snapshot()
+	if s.Commands != 5 {
+		t.Errorf("Commands = %d, want 5", s.Commands)
+	}
+	wantErrors := map[string]uint64{ssdb.StatusNotFound: 1, ssdb.StatusError: 1, ssdb.StatusTimeout: 1}
+	for status, n := range wantErrors {
+		if s.Errors[status] != n {
+			t.Errorf("Errors[%s] = %d, want %d", status, s.Errors[status], n)
+		}
+	}
+	if s.Errors[ssdb.StatusOK] != 0 {
+		t.Errorf("ok counted as an error")
+	}
+	if s.Timeouts != 1 {
+		t.Errorf("Timeouts = %d, want 1", s.Timeouts)
+	}
+	if s.BytesSent == 0 || s.BytesReceived == 0 {
+		t.Errorf("BytesSent = %d, BytesReceived = %d", s.BytesSent, s.BytesReceived)
+	}
+	get := s.Latency["get"]
+	if get.Count != 3 {
+		t.Errorf("get latency count = %d, want 3", get.Count)
+	}
+	var sum uint64
+	for _, n := range get.Counts {
+		sum += n
+	}
+	if sum != get.Count || get.Sum < 20*time.Millisecond {
+		t.Errorf("get histogram = %+v", get)
+	}
+
+	sent := s.BytesSent
+	srv.CloseConnections()
+	waitFor(t, c.Ping)
+	s = m.Snapshot()
+	if s.Reconnects == 0 {
+		t.Error("Reconnects = 0 after a reconnect")
+	}
+	if s.BytesSent <= sent {
+		t.Error("BytesSent did not grow")
+	}
+
+	m.Reset()
+	if s := m.Snapshot(); s.Commands != 0 || len(s.Errors) != 0 || len(s.Latency) != 0 {
+		t.Errorf("Snapshot after Reset = %+v", s)
+	}
+}
+
+func TestMetricsZip(t *testing.T) {
+	srv := newServer(t)
+	m := ssdb.NewMetricsCollector()
+	c := dial(t, srv, ssdb.WithMetrics(m), ssdb.WithZip(true))
+	value := string(make([]byte, 4096))
+	if err := c.Set("a", value); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get("a"); err != nil || v != value {
+		t.Fatalf("Get = %d bytes, %v", len(v), err)
+	}
+	s := m.Snapshot()
+	if s.ZipRaw == 0 || s.ZipCompressed == 0 {
+		t.Fatalf("ZipRaw = %d, ZipCompressed = %d", s.ZipRaw, s.ZipCompressed)
+	}
+	if r := s.ZipRatio(); r <= 0 || r >= 1 {
+		t.Errorf("ZipRatio = %v, want a compression", r)
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+	m := ssdb.NewMetricsCollectorBuckets([]time.Duration{10 * time.Millisecond, time.Millisecond})
+	m.ObserveCommand("get", ssdb.StatusOK, time.Millisecond)
+	m.ObserveCommand("get", ssdb.StatusOK, 5*time.Millisecond)
+	m.ObserveCommand("get", ssdb.StatusOK, time.Second)
+	m.ObserveReconnect(ssdb.ReconnectEvent{State: ssdb.StateConnected})
+	m.ObserveReconnect(ssdb.ReconnectEvent{State: ssdb.StateReconnecting, Err: errors.New("refused")})
+
+	s := m.Snapshot()
+	h := s.Latency["get"]
+	if want := []time.Duration{time.Millisecond, 10 * time.Millisecond}; len(h.Buckets) != 2 || h.Buckets[0] != want[0] || h.Buckets[1] != want[1] {
+		t.Fatalf("Buckets = %v, want %v", h.Buckets, want)
+	}
+	if want := []uint64{1, 1, 1}; len(h.Counts) != 3 || h.Counts[0] != want[0] || h.Counts[1] != want[1] || h.Counts[2] != want[2] {
+		t.Fatalf("Counts = %v, want %v", h.Counts, want)
+	}
+	if h.Sum != time.Second+6*time.Millisecond {
+		t.Fatalf("Sum = %v", h.Sum)
+	}
+	if s.Reconnects != 1 || s.ReconnectAttempts != 1 {
+		t.Fatalf("Reconnects = %d, ReconnectAttempts = %d, want 1 and 1", s.Reconnects, s.ReconnectAttempts)
+	}
+
+	// The snapshot is a copy.
+	h.Counts[0] = 99
+	if m.Snapshot().Latency["get"].Counts[0] != 1 {
+		t.Fatal("the snapshot shares the counts of the collector")
+	}
+}
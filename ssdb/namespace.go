@@ -0,0 +1,775 @@
+package ssdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// namespace is a key prefix, it travels in the context of the commands of a
+// NamespacedClient and is applied by DoContext and Pipeline.ExecContext.
+type namespace string
+
+type namespaceKey struct{}
+
+// withNamespace adds prefix to the namespace of ctx.
+func withNamespace(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespaceFrom(ctx)+namespace(prefix))
+}
+
+// withoutNamespace removes the namespace of ctx, for the commands of a
+// routed client whose keys are already prefixed.
+func withoutNamespace(ctx context.Context) context.Context {
+	if namespaceFrom(ctx) == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, namespaceKey{}, namespace(""))
+}
+
+func namespaceFrom(ctx context.Context) namespace {
+	ns, _ := ctx.Value(namespaceKey{}).(namespace)
+	return ns
+}
+
+// command returns args with the keys prefixed and the rule of the command.
+// Commands missing from commandKeys are refused so that they do not reach
+// keys out of the namespace.
+func (ns namespace) command(args []interface{}) ([]interface{}, keyRule, error) {
+	if len(args) == 0 {
+		return args, keyRule{}, nil
+	}
+	name := cmdName(args)
+	rule, ok := commandKeys[name]
+	if !ok {
+		return nil, rule, fmt.Errorf("ssdb: command %q is not supported in a namespace", name)
+	}
+	out := append([]interface{}(nil), args...)
+	switch rule.args {
+	case keyFirst:
+		if len(out) > 1 {
+			out[1] = ns.key(out[1])
+		}
+	case keyAll:
+		for i := 1; i < len(out); i++ {
+			out[i] = ns.key(out[i])
+		}
+	case keyPairs:
+		for i := 1; i < len(out); i += 2 {
+			out[i] = ns.key(out[i])
+		}
+	case keyRange:
+		if len(out) > 2 {
+			out[1], out[2] = ns.bounds(argString(out[1]), argString(out[2]), rule.reverse)
+		}
+	}
+	return out, rule, nil
+}
+
+func (ns namespace) key(arg interface{}) string {
+	return string(ns) + argString(arg)
+}
+
+// bounds returns the key range of a range command, an empty bound is the
+// bound of the namespace.
+func (ns namespace) bounds(start, end string, reverse bool) (string, string) {
+	first, last := string(ns), ns.end()
+	if reverse {
+		first, last = last, first
+	}
+	if start != "" {
+		first = string(ns) + start
+	}
+	if end != "" {
+		last = string(ns) + end
+	}
+	return first, last
+}
+
+// end returns the smallest key greater than all the keys of the namespace.
+func (ns namespace) end() string {
+	b := []byte(ns)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+// response filters the keys of a list response and strips their prefix, the
+// prefix itself is the empty key and is left out.
+func (ns namespace) response(rule keyRule, resp []string) []string {
+	if !rule.list || len(resp) == 0 || resp[0] != StatusOK {
+		return resp
+	}
+	step := 1
+	if rule.pairs {
+		step = 2
+	}
+	out := resp[:1]
+	for i := 1; i+step <= len(resp); i += step {
+		key, ok := strings.CutPrefix(resp[i], string(ns))
+		if !ok || key == "" {
+			continue
+		}
+		out = append(out, key)
+		out = append(out, resp[i+1:i+step]...)
+	}
+	return out
+}
+
+// NamespacedClient is a view of a Client that prefixes the keys and the
+// hash, sorted set and queue names of its commands, so that several services
+// can share a server. The listing commands, e.g. Scan, HashList, ZList and
+// QueueList, only return the keys of the namespace, without the prefix, and
+// their empty range bounds stand for the bounds of the namespace. The fields
+// of a hash and the members of a sorted set are not prefixed.
+//
+// Do and Pipeline accept the commands of the helpers, other commands such as
+// flushdb are refused. The view shares the connection of the client, closing
+// the client closes it.
+type NamespacedClient struct {
+	c      *Client
+	prefix string
+}
+
+// Namespaced returns a view of c in the namespace prefix, e.g. "svc:".
+func Namespaced(c *Client, prefix string) *NamespacedClient {
+	return &NamespacedClient{c: c, prefix: prefix}
+}
+
+// Namespaced returns a view in the namespace prefix nested in the namespace
+// of n.
+func (n *NamespacedClient) Namespaced(prefix string) *NamespacedClient {
+	return &NamespacedClient{c: n.c, prefix: n.prefix + prefix}
+}
+
+// Client returns the client of the view.
+func (n *NamespacedClient) Client() *Client {
+	return n.c
+}
+
+// Prefix returns the prefix of the namespace.
+func (n *NamespacedClient) Prefix() string {
+	return n.prefix
+}
+
+// Codec returns the codec of the client, see GetAs.
+func (n *NamespacedClient) Codec() Codec {
+	return n.c.Codec()
+}
+
+func (n *NamespacedClient) ctx(ctx context.Context) context.Context {
+	return withNamespace(ctx, n.prefix)
+}
+
+func (n *NamespacedClient) Do(args ...interface{}) ([]string, error) {
+	return n.DoContext(context.Background(), args...)
+}
+
+func (n *NamespacedClient) DoContext(ctx context.Context, args ...interface{}) ([]string, error) {
+	return n.c.DoContext(n.ctx(ctx), args...)
+}
+
+func (n *NamespacedClient) ProcessCmd(cmd string, args []interface{}) (interface{}, error) {
+	return n.ProcessCmdContext(context.Background(), cmd, args)
+}
+
+func (n *NamespacedClient) ProcessCmdContext(ctx context.Context, cmd string, args []interface{}) (interface{}, error) {
+	return n.c.ProcessCmdContext(n.ctx(ctx), cmd, args)
+}
+
+// Pipeline returns a pipeline whose commands run in the namespace.
+func (n *NamespacedClient) Pipeline() *Pipeline {
+	return &Pipeline{c: n.c, ns: namespace(n.prefix)}
+}
+
+func (n *NamespacedClient) MultiMode(args [][]interface{}) ([]string, error) {
+	p := n.Pipeline()
+	for _, v := range args {
+		p.Append(v...)
+	}
+	results, err := p.Exec()
+	if err != nil {
+		return nil, err
+	}
+	var resps []string
+	for _, resp := range results {
+		resps = append(resps, strings.Join(resp, ","))
+	}
+	return resps, nil
+}
+
+func (n *NamespacedClient) BatchSend(batchArgs [][]interface{}) error {
+	ns := namespace(n.prefix)
+	cmds := make([][]interface{}, len(batchArgs))
+	for i, args := range batchArgs {
+		var err error
+		if cmds[i], _, err = ns.command(args); err != nil {
+			return err
+		}
+	}
+	return n.c.BatchSend(cmds)
+}
+
+func (n *NamespacedClient) MultiHashSet(parts []HashData, connNum int) (interface{}, error) {
+	prefixed := make([]HashData, len(parts))
+	for i, part := range parts {
+		part.HashName = n.prefix + part.HashName
+		prefixed[i] = part
+	}
+	return n.c.MultiHashSet(prefixed, connNum)
+}
+
+func (n *NamespacedClient) Set(key string, val string) error {
+	return n.SetContext(context.Background(), key, val)
+}
+
+func (n *NamespacedClient) SetContext(ctx context.Context, key string, val string) error {
+	return n.c.SetContext(n.ctx(ctx), key, val)
+}
+
+func (n *NamespacedClient) Get(key string) (string, error) {
+	return n.GetContext(context.Background(), key)
+}
+
+func (n *NamespacedClient) GetContext(ctx context.Context, key string) (string, error) {
+	return n.c.GetContext(n.ctx(ctx), key)
+}
+
+func (n *NamespacedClient) Del(key string) error {
+	return n.DelContext(context.Background(), key)
+}
+
+func (n *NamespacedClient) DelContext(ctx context.Context, key string) error {
+	return n.c.DelContext(n.ctx(ctx), key)
+}
+
+func (n *NamespacedClient) SetX(key string, val string, ttl int) error {
+	return n.SetXContext(context.Background(), key, val, ttl)
+}
+
+func (n *NamespacedClient) SetXContext(ctx context.Context, key string, val string, ttl int) error {
+	return n.c.SetXContext(n.ctx(ctx), key, val, ttl)
+}
+
+func (n *NamespacedClient) Scan(start string, end string, limit int) (map[string]string, error) {
+	return n.ScanContext(context.Background(), start, end, limit)
+}
+
+func (n *NamespacedClient) ScanContext(ctx context.Context, start string, end string, limit int) (map[string]string, error) {
+	return n.c.ScanContext(n.ctx(ctx), start, end, limit)
+}
+
+func (n *NamespacedClient) Expire(key string, ttl int) (bool, error) {
+	return n.ExpireContext(context.Background(), key, ttl)
+}
+
+func (n *NamespacedClient) ExpireContext(ctx context.Context, key string, ttl int) (bool, error) {
+	return n.c.ExpireContext(n.ctx(ctx), key, ttl)
+}
+
+func (n *NamespacedClient) KeyTTL(key string) (int64, error) {
+	return n.KeyTTLContext(context.Background(), key)
+}
+
+func (n *NamespacedClient) KeyTTLContext(ctx context.Context, key string) (int64, error) {
+	return n.c.KeyTTLContext(n.ctx(ctx), key)
+}
+
+func (n *NamespacedClient) SetNew(key string, val string) (bool, error) {
+	return n.SetNewContext(context.Background(), key, val)
+}
+
+func (n *NamespacedClient) SetNewContext(ctx context.Context, key string, val string) (bool, error) {
+	return n.c.SetNewContext(n.ctx(ctx), key, val)
+}
+
+func (n *NamespacedClient) GetSet(key string, val string) (string, error) {
+	return n.GetSetContext(context.Background(), key, val)
+}
+
+func (n *NamespacedClient) GetSetContext(ctx context.Context, key string, val string) (string, error) {
+	return n.c.GetSetContext(n.ctx(ctx), key, val)
+}
+
+func (n *NamespacedClient) Incr(key string, val int64) (int64, error) {
+	return n.IncrContext(context.Background(), key, val)
+}
+
+func (n *NamespacedClient) IncrContext(ctx context.Context, key string, val int64) (int64, error) {
+	return n.c.IncrContext(n.ctx(ctx), key, val)
+}
+
+func (n *NamespacedClient) Exists(key string) (bool, error) {
+	return n.ExistsContext(context.Background(), key)
+}
+
+func (n *NamespacedClient) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return n.c.ExistsContext(n.ctx(ctx), key)
+}
+
+func (n *NamespacedClient) HashSet(hash string, key string, val string) error {
+	return n.HashSetContext(context.Background(), hash, key, val)
+}
+
+func (n *NamespacedClient) HashSetContext(ctx context.Context, hash string, key string, val string) error {
+	return n.c.HashSetContext(n.ctx(ctx), hash, key, val)
+}
+
+func (n *NamespacedClient) HashGet(hash string, key string) (string, error) {
+	return n.HashGetContext(context.Background(), hash, key)
+}
+
+func (n *NamespacedClient) HashGetContext(ctx context.Context, hash string, key string) (string, error) {
+	return n.c.HashGetContext(n.ctx(ctx), hash, key)
+}
+
+func (n *NamespacedClient) HashDel(hash string, key string) error {
+	return n.HashDelContext(context.Background(), hash, key)
+}
+
+func (n *NamespacedClient) HashDelContext(ctx context.Context, hash string, key string) error {
+	return n.c.HashDelContext(n.ctx(ctx), hash, key)
+}
+
+func (n *NamespacedClient) HashIncr(hash string, key string, val int64) (int64, error) {
+	return n.HashIncrContext(context.Background(), hash, key, val)
+}
+
+func (n *NamespacedClient) HashIncrContext(ctx context.Context, hash string, key string, val int64) (int64, error) {
+	return n.c.HashIncrContext(n.ctx(ctx), hash, key, val)
+}
+
+func (n *NamespacedClient) HashExists(hash string, key string) (bool, error) {
+	return n.HashExistsContext(context.Background(), hash, key)
+}
+
+func (n *NamespacedClient) HashExistsContext(ctx context.Context, hash string, key string) (bool, error) {
+	return n.c.HashExistsContext(n.ctx(ctx), hash, key)
+}
+
+func (n *NamespacedClient) HashSize(hash string) (int64, error) {
+	return n.HashSizeContext(context.Background(), hash)
+}
+
+func (n *NamespacedClient) HashSizeContext(ctx context.Context, hash string) (int64, error) {
+	return n.c.HashSizeContext(n.ctx(ctx), hash)
+}
+
+func (n *NamespacedClient) HashList(start string, end string, limit int) ([]string, error) {
+	return n.HashListContext(context.Background(), start, end, limit)
+}
+
+func (n *NamespacedClient) HashListContext(ctx context.Context, start string, end string, limit int) ([]string, error) {
+	return n.c.HashListContext(n.ctx(ctx), start, end, limit)
+}
+
+func (n *NamespacedClient) HashKeys(hash string, start string, end string, limit int) ([]string, error) {
+	return n.HashKeysContext(context.Background(), hash, start, end, limit)
+}
+
+func (n *NamespacedClient) HashKeysContext(ctx context.Context, hash string, start string, end string, limit int) ([]string, error) {
+	return n.c.HashKeysContext(n.ctx(ctx), hash, start, end, limit)
+}
+
+func (n *NamespacedClient) HashKeysAll(hash string) ([]string, error) {
+	return n.HashKeysAllContext(context.Background(), hash)
+}
+
+func (n *NamespacedClient) HashKeysAllContext(ctx context.Context, hash string) ([]string, error) {
+	return n.c.HashKeysAllContext(n.ctx(ctx), hash)
+}
+
+func (n *NamespacedClient) HashGetAll(hash string) (map[string]string, error) {
+	return n.HashGetAllContext(context.Background(), hash)
+}
+
+func (n *NamespacedClient) HashGetAllContext(ctx context.Context, hash string) (map[string]string, error) {
+	return n.c.HashGetAllContext(n.ctx(ctx), hash)
+}
+
+func (n *NamespacedClient) HashGetAllLite(hash string) (map[string]string, error) {
+	return n.HashGetAllLiteContext(context.Background(), hash)
+}
+
+func (n *NamespacedClient) HashGetAllLiteContext(ctx context.Context, hash string) (map[string]string, error) {
+	return n.c.HashGetAllLiteContext(n.ctx(ctx), hash)
+}
+
+func (n *NamespacedClient) HashScan(hash string, start string, end string, limit int) (map[string]string, error) {
+	return n.HashScanContext(context.Background(), hash, start, end, limit)
+}
+
+func (n *NamespacedClient) HashScanContext(ctx context.Context, hash string, start string, end string, limit int) (map[string]string, error) {
+	return n.c.HashScanContext(n.ctx(ctx), hash, start, end, limit)
+}
+
+func (n *NamespacedClient) HashRScan(hash string, start string, end string, limit int) (map[string]string, error) {
+	return n.HashRScanContext(context.Background(), hash, start, end, limit)
+}
+
+func (n *NamespacedClient) HashRScanContext(ctx context.Context, hash string, start string, end string, limit int) (map[string]string, error) {
+	return n.c.HashRScanContext(n.ctx(ctx), hash, start, end, limit)
+}
+
+func (n *NamespacedClient) HashMultiSet(hash string, data map[string]string) error {
+	return n.HashMultiSetContext(context.Background(), hash, data)
+}
+
+func (n *NamespacedClient) HashMultiSetContext(ctx context.Context, hash string, data map[string]string) error {
+	return n.c.HashMultiSetContext(n.ctx(ctx), hash, data)
+}
+
+func (n *NamespacedClient) HashMultiGet(hash string, keys []string) (map[string]string, error) {
+	return n.HashMultiGetContext(context.Background(), hash, keys)
+}
+
+func (n *NamespacedClient) HashMultiGetContext(ctx context.Context, hash string, keys []string) (map[string]string, error) {
+	return n.c.HashMultiGetContext(n.ctx(ctx), hash, keys)
+}
+
+func (n *NamespacedClient) HashSetStruct(hash string, v interface{}) error {
+	return n.HashSetStructContext(context.Background(), hash, v)
+}
+
+func (n *NamespacedClient) HashSetStructContext(ctx context.Context, hash string, v interface{}) error {
+	return n.c.HashSetStructContext(n.ctx(ctx), hash, v)
+}
+
+func (n *NamespacedClient) HashGetStruct(hash string, ptr interface{}) error {
+	return n.HashGetStructContext(context.Background(), hash, ptr)
+}
+
+func (n *NamespacedClient) HashGetStructContext(ctx context.Context, hash string, ptr interface{}) error {
+	return n.c.HashGetStructContext(n.ctx(ctx), hash, ptr)
+}
+
+func (n *NamespacedClient) HashMultiDel(hash string, keys []string) error {
+	return n.HashMultiDelContext(context.Background(), hash, keys)
+}
+
+func (n *NamespacedClient) HashMultiDelContext(ctx context.Context, hash string, keys []string) error {
+	return n.c.HashMultiDelContext(n.ctx(ctx), hash, keys)
+}
+
+func (n *NamespacedClient) HashClear(hash string) (int64, error) {
+	return n.HashClearContext(context.Background(), hash)
+}
+
+func (n *NamespacedClient) HashClearContext(ctx context.Context, hash string) (int64, error) {
+	return n.c.HashClearContext(n.ctx(ctx), hash)
+}
+
+func (n *NamespacedClient) ZSet(name string, key string, score int64) error {
+	return n.ZSetContext(context.Background(), name, key, score)
+}
+
+func (n *NamespacedClient) ZSetContext(ctx context.Context, name string, key string, score int64) error {
+	return n.c.ZSetContext(n.ctx(ctx), name, key, score)
+}
+
+func (n *NamespacedClient) ZGet(name string, key string) (int64, error) {
+	return n.ZGetContext(context.Background(), name, key)
+}
+
+func (n *NamespacedClient) ZGetContext(ctx context.Context, name string, key string) (int64, error) {
+	return n.c.ZGetContext(n.ctx(ctx), name, key)
+}
+
+func (n *NamespacedClient) ZDel(name string, key string) error {
+	return n.ZDelContext(context.Background(), name, key)
+}
+
+func (n *NamespacedClient) ZDelContext(ctx context.Context, name string, key string) error {
+	return n.c.ZDelContext(n.ctx(ctx), name, key)
+}
+
+func (n *NamespacedClient) ZIncr(name string, key string, num int64) (int64, error) {
+	return n.ZIncrContext(context.Background(), name, key, num)
+}
+
+func (n *NamespacedClient) ZIncrContext(ctx context.Context, name string, key string, num int64) (int64, error) {
+	return n.c.ZIncrContext(n.ctx(ctx), name, key, num)
+}
+
+func (n *NamespacedClient) ZSize(name string) (int64, error) {
+	return n.ZSizeContext(context.Background(), name)
+}
+
+func (n *NamespacedClient) ZSizeContext(ctx context.Context, name string) (int64, error) {
+	return n.c.ZSizeContext(n.ctx(ctx), name)
+}
+
+func (n *NamespacedClient) ZRank(name string, key string) (int64, error) {
+	return n.ZRankContext(context.Background(), name, key)
+}
+
+func (n *NamespacedClient) ZRankContext(ctx context.Context, name string, key string) (int64, error) {
+	return n.c.ZRankContext(n.ctx(ctx), name, key)
+}
+
+func (n *NamespacedClient) ZRRank(name string, key string) (int64, error) {
+	return n.ZRRankContext(context.Background(), name, key)
+}
+
+func (n *NamespacedClient) ZRRankContext(ctx context.Context, name string, key string) (int64, error) {
+	return n.c.ZRRankContext(n.ctx(ctx), name, key)
+}
+
+func (n *NamespacedClient) ZRange(name string, offset int, limit int) ([]ScoredMember, error) {
+	return n.ZRangeContext(context.Background(), name, offset, limit)
+}
+
+func (n *NamespacedClient) ZRangeContext(ctx context.Context, name string, offset int, limit int) ([]ScoredMember, error) {
+	return n.c.ZRangeContext(n.ctx(ctx), name, offset, limit)
+}
+
+func (n *NamespacedClient) ZRRange(name string, offset int, limit int) ([]ScoredMember, error) {
+	return n.ZRRangeContext(context.Background(), name, offset, limit)
+}
+
+func (n *NamespacedClient) ZRRangeContext(ctx context.Context, name string, offset int, limit int) ([]ScoredMember, error) {
+	return n.c.ZRRangeContext(n.ctx(ctx), name, offset, limit)
+}
+
+func (n *NamespacedClient) ZScan(name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	return n.ZScanContext(context.Background(), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (n *NamespacedClient) ZScanContext(ctx context.Context, name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	return n.c.ZScanContext(n.ctx(ctx), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (n *NamespacedClient) ZRScan(name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	return n.ZRScanContext(context.Background(), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (n *NamespacedClient) ZRScanContext(ctx context.Context, name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	return n.c.ZRScanContext(n.ctx(ctx), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (n *NamespacedClient) ZKeys(name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]string, error) {
+	return n.ZKeysContext(context.Background(), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (n *NamespacedClient) ZKeysContext(ctx context.Context, name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]string, error) {
+	return n.c.ZKeysContext(n.ctx(ctx), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (n *NamespacedClient) ZCount(name string, scoreStart string, scoreEnd string) (int64, error) {
+	return n.ZCountContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZCountContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (int64, error) {
+	return n.c.ZCountContext(n.ctx(ctx), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZSum(name string, scoreStart string, scoreEnd string) (int64, error) {
+	return n.ZSumContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZSumContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (int64, error) {
+	return n.c.ZSumContext(n.ctx(ctx), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZAvg(name string, scoreStart string, scoreEnd string) (float64, error) {
+	return n.ZAvgContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZAvgContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (float64, error) {
+	return n.c.ZAvgContext(n.ctx(ctx), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZRemRangeByRank(name string, start int, end int) (int64, error) {
+	return n.ZRemRangeByRankContext(context.Background(), name, start, end)
+}
+
+func (n *NamespacedClient) ZRemRangeByRankContext(ctx context.Context, name string, start int, end int) (int64, error) {
+	return n.c.ZRemRangeByRankContext(n.ctx(ctx), name, start, end)
+}
+
+func (n *NamespacedClient) ZRemRangeByScore(name string, scoreStart string, scoreEnd string) (int64, error) {
+	return n.ZRemRangeByScoreContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZRemRangeByScoreContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (int64, error) {
+	return n.c.ZRemRangeByScoreContext(n.ctx(ctx), name, scoreStart, scoreEnd)
+}
+
+func (n *NamespacedClient) ZPopFront(name string, limit int) ([]ScoredMember, error) {
+	return n.ZPopFrontContext(context.Background(), name, limit)
+}
+
+func (n *NamespacedClient) ZPopFrontContext(ctx context.Context, name string, limit int) ([]ScoredMember, error) {
+	return n.c.ZPopFrontContext(n.ctx(ctx), name, limit)
+}
+
+func (n *NamespacedClient) ZPopBack(name string, limit int) ([]ScoredMember, error) {
+	return n.ZPopBackContext(context.Background(), name, limit)
+}
+
+func (n *NamespacedClient) ZPopBackContext(ctx context.Context, name string, limit int) ([]ScoredMember, error) {
+	return n.c.ZPopBackContext(n.ctx(ctx), name, limit)
+}
+
+func (n *NamespacedClient) MultiZSet(name string, members []ScoredMember) error {
+	return n.MultiZSetContext(context.Background(), name, members)
+}
+
+func (n *NamespacedClient) MultiZSetContext(ctx context.Context, name string, members []ScoredMember) error {
+	return n.c.MultiZSetContext(n.ctx(ctx), name, members)
+}
+
+func (n *NamespacedClient) MultiZGet(name string, keys []string) ([]ScoredMember, error) {
+	return n.MultiZGetContext(context.Background(), name, keys)
+}
+
+func (n *NamespacedClient) MultiZGetContext(ctx context.Context, name string, keys []string) ([]ScoredMember, error) {
+	return n.c.MultiZGetContext(n.ctx(ctx), name, keys)
+}
+
+func (n *NamespacedClient) MultiZDel(name string, keys []string) error {
+	return n.MultiZDelContext(context.Background(), name, keys)
+}
+
+func (n *NamespacedClient) MultiZDelContext(ctx context.Context, name string, keys []string) error {
+	return n.c.MultiZDelContext(n.ctx(ctx), name, keys)
+}
+
+func (n *NamespacedClient) ZList(start string, end string, limit int) ([]string, error) {
+	return n.ZListContext(context.Background(), start, end, limit)
+}
+
+func (n *NamespacedClient) ZListContext(ctx context.Context, start string, end string, limit int) ([]string, error) {
+	return n.c.ZListContext(n.ctx(ctx), start, end, limit)
+}
+
+func (n *NamespacedClient) ZClear(name string) (int64, error) {
+	return n.ZClearContext(context.Background(), name)
+}
+
+func (n *NamespacedClient) ZClearContext(ctx context.Context, name string) (int64, error) {
+	return n.c.ZClearContext(n.ctx(ctx), name)
+}
+
+func (n *NamespacedClient) QueuePushFront(name string, items ...string) (int64, error) {
+	return n.QueuePushFrontContext(context.Background(), name, items...)
+}
+
+func (n *NamespacedClient) QueuePushFrontContext(ctx context.Context, name string, items ...string) (int64, error) {
+	return n.c.QueuePushFrontContext(n.ctx(ctx), name, items...)
+}
+
+func (n *NamespacedClient) QueuePushBack(name string, items ...string) (int64, error) {
+	return n.QueuePushBackContext(context.Background(), name, items...)
+}
+
+func (n *NamespacedClient) QueuePushBackContext(ctx context.Context, name string, items ...string) (int64, error) {
+	return n.c.QueuePushBackContext(n.ctx(ctx), name, items...)
+}
+
+func (n *NamespacedClient) QueuePopFront(name string, size int) ([]string, error) {
+	return n.QueuePopFrontContext(context.Background(), name, size)
+}
+
+func (n *NamespacedClient) QueuePopFrontContext(ctx context.Context, name string, size int) ([]string, error) {
+	return n.c.QueuePopFrontContext(n.ctx(ctx), name, size)
+}
+
+func (n *NamespacedClient) QueuePopBack(name string, size int) ([]string, error) {
+	return n.QueuePopBackContext(context.Background(), name, size)
+}
+
+func (n *NamespacedClient) QueuePopBackContext(ctx context.Context, name string, size int) ([]string, error) {
+	return n.c.QueuePopBackContext(n.ctx(ctx), name, size)
+}
+
+func (n *NamespacedClient) QueueFront(name string) (string, error) {
+	return n.QueueFrontContext(context.Background(), name)
+}
+
+func (n *NamespacedClient) QueueFrontContext(ctx context.Context, name string) (string, error) {
+	return n.c.QueueFrontContext(n.ctx(ctx), name)
+}
+
+func (n *NamespacedClient) QueueBack(name string) (string, error) {
+	return n.QueueBackContext(context.Background(), name)
+}
+
+func (n *NamespacedClient) QueueBackContext(ctx context.Context, name string) (string, error) {
+	return n.c.QueueBackContext(n.ctx(ctx), name)
+}
+
+func (n *NamespacedClient) QueueSize(name string) (int64, error) {
+	return n.QueueSizeContext(context.Background(), name)
+}
+
+func (n *NamespacedClient) QueueSizeContext(ctx context.Context, name string) (int64, error) {
+	return n.c.QueueSizeContext(n.ctx(ctx), name)
+}
+
+func (n *NamespacedClient) QueueGet(name string, index int64) (string, error) {
+	return n.QueueGetContext(context.Background(), name, index)
+}
+
+func (n *NamespacedClient) QueueGetContext(ctx context.Context, name string, index int64) (string, error) {
+	return n.c.QueueGetContext(n.ctx(ctx), name, index)
+}
+
+func (n *NamespacedClient) QueueSet(name string, index int64, val string) error {
+	return n.QueueSetContext(context.Background(), name, index, val)
+}
+
+func (n *NamespacedClient) QueueSetContext(ctx context.Context, name string, index int64, val string) error {
+	return n.c.QueueSetContext(n.ctx(ctx), name, index, val)
+}
+
+func (n *NamespacedClient) QueueRange(name string, offset int, limit int) ([]string, error) {
+	return n.QueueRangeContext(context.Background(), name, offset, limit)
+}
+
+func (n *NamespacedClient) QueueRangeContext(ctx context.Context, name string, offset int, limit int) ([]string, error) {
+	return n.c.QueueRangeContext(n.ctx(ctx), name, offset, limit)
+}
+
+func (n *NamespacedClient) QueueSlice(name string, begin int, end int) ([]string, error) {
+	return n.QueueSliceContext(context.Background(), name, begin, end)
+}
+
+func (n *NamespacedClient) QueueSliceContext(ctx context.Context, name string, begin int, end int) ([]string, error) {
+	return n.c.QueueSliceContext(n.ctx(ctx), name, begin, end)
+}
+
+func (n *NamespacedClient) QueueTrimFront(name string, size int) (int64, error) {
+	return n.QueueTrimFrontContext(context.Background(), name, size)
+}
+
+func (n *NamespacedClient) QueueTrimFrontContext(ctx context.Context, name string, size int) (int64, error) {
+	return n.c.QueueTrimFrontContext(n.ctx(ctx), name, size)
+}
+
+func (n *NamespacedClient) QueueTrimBack(name string, size int) (int64, error) {
+	return n.QueueTrimBackContext(context.Background(), name, size)
+}
+
+func (n *NamespacedClient) QueueTrimBackContext(ctx context.Context, name string, size int) (int64, error) {
+	return n.c.QueueTrimBackContext(n.ctx(ctx), name, size)
+}
+
+func (n *NamespacedClient) QueueClear(name string) (int64, error) {
+	return n.QueueClearContext(context.Background(), name)
+}
+
+func (n *NamespacedClient) QueueClearContext(ctx context.Context, name string) (int64, error) {
+	return n.c.QueueClearContext(n.ctx(ctx), name)
+}
+
+func (n *NamespacedClient) QueueList(start string, end string, limit int) ([]string, error) {
+	return n.QueueListContext(context.Background(), start, end, limit)
+}
+
+func (n *NamespacedClient) QueueListContext(ctx context.Context, start string, end string, limit int) ([]string, error) {
+	return n.c.QueueListContext(n.ctx(ctx), start, end, limit)
+}
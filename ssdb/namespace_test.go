@@ -0,0 +1,199 @@
+package ssdb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+)
+
+func TestNamespacePrefix(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	n := ssdb.Namespaced(c, "svc:")
+
+	if err := n.Set("k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.HashSet("h", "f", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.ZSet("z", "m", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.QueuePushBack("q", "i"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Do("multi_set", "m1", "1", "m2", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The raw client sees the prefixed names, the fields and members are
+	// left as they are.
+	if v, err := c.Get("svc:k"); err != nil || v != "v" {
+		t.Fatalf("raw Get = %q, %v", v, err)
+	}
+	if v, err := c.HashGet("svc:h", "f"); err != nil || v != "v" {
+		t.Fatalf("raw HashGet = %q, %v", v, err)
+	}
+	if s, err := c.ZGet("svc:z", "m"); err != nil || s != 1 {
+		t.Fatalf("raw ZGet = %d, %v", s, err)
+	}
+	if v, err := c.QueueFront("svc:q"); err != nil || v != "i" {
+		t.Fatalf("raw QueueFront = %q, %v", v, err)
+	}
+	if v, err := c.Get("svc:m2"); err != nil || v != "2" {
+		t.Fatalf("raw Get of a multi_set key = %q, %v", v, err)
+	}
+	if _, err := c.Get("k"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("Get of the unprefixed key: %v, want ErrNotFound", err)
+	}
+
+	if v, err := n.Get("k"); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+	if resp, err := n.Do("multi_get", "m1", "m2"); err != nil || !reflect.DeepEqual(resp, []string{"ok", "m1", "1", "m2", "2"}) {
+		t.Fatalf("multi_get = %q, %v", resp, err)
+	}
+}
+
+func TestNamespaceLists(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	n := ssdb.Namespaced(c, "svc:")
+	// Neighbours of the namespace on both sides, and the prefix itself
+	// which is the empty key of the namespace.
+	for _, key := range []string{"sva", "svc", "svc:", "svc:a", "svc:b", "svc;", "svd"} {
+		if err := c.Set(key, "v"); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.HashSet(key, "f", "v"); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.ZSet(key, "m", 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.QueuePushBack(key, "i"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"a", "b"}
+	keys := func(resp []string, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if got := keys(n.HashList("", "", 10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("HashList = %q, want %q", got, want)
+	}
+	if got := keys(n.ZList("", "", 10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZList = %q, want %q", got, want)
+	}
+	if got := keys(n.QueueList("", "", 10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("QueueList = %q, want %q", got, want)
+	}
+	if got := keys(n.Do("keys", "", "", 10)); !reflect.DeepEqual(got, []string{"ok", "a", "b"}) {
+		t.Errorf("keys = %q", got)
+	}
+	if got := keys(n.Do("rscan", "", "", 10)); !reflect.DeepEqual(got, []string{"ok", "b", "v", "a", "v"}) {
+		t.Errorf("rscan = %q", got)
+	}
+	m, err := n.Scan("", "", 10)
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "v", "b": "v"}) {
+		t.Errorf("Scan = %v, %v", m, err)
+	}
+
+	// The bounds given are in the namespace, an empty end is the end of the
+	// namespace.
+	if got := keys(n.HashList("a", "", 10)); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("HashList from a = %q, want [b]", got)
+	}
+	if got := keys(n.HashList("", "a", 10)); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("HashList to a = %q, want [a]", got)
+	}
+	if got := keys(n.Do("rscan", "b", "", 10)); !reflect.DeepEqual(got, []string{"ok", "a", "v"}) {
+		t.Errorf("rscan from b = %q", got)
+	}
+	if got := keys(n.HashList("", "", 1)); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("HashList limit 1 = %q, want [a]", got)
+	}
+}
+
+func TestNamespaceNested(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	outer := ssdb.Namespaced(c, "svc:")
+	inner := outer.Namespaced("tenant:")
+	if inner.Prefix() != "svc:tenant:" {
+		t.Fatalf("Prefix = %q", inner.Prefix())
+	}
+	if err := inner.Set("k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get("svc:tenant:k"); err != nil || v != "v" {
+		t.Fatalf("raw Get = %q, %v", v, err)
+	}
+	if v, err := outer.Get("tenant:k"); err != nil || v != "v" {
+		t.Fatalf("outer Get = %q, %v", v, err)
+	}
+	m, err := outer.Scan("", "", 10)
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"tenant:k": "v"}) {
+		t.Fatalf("outer Scan = %v, %v", m, err)
+	}
+	m, err = inner.Scan("", "", 10)
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"k": "v"}) {
+		t.Fatalf("inner Scan = %v, %v", m, err)
+	}
+}
+
+func TestNamespacePipeline(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	n := ssdb.Namespaced(c, "svc:")
+	p := n.Pipeline()
+	p.Append("set", "a", "1")
+	p.Append("keys", "", "", 10)
+	resps, err := p.Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resps[1], []string{"ok", "a"}) {
+		t.Fatalf("keys in a pipeline = %q", resps[1])
+	}
+	if err := n.BatchSend([][]interface{}{{"set", "b", "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get("svc:b"); err != nil || v != "2" {
+		t.Fatalf("raw Get of a BatchSend key = %q, %v", v, err)
+	}
+}
+
+func TestNamespaceRefused(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	n := ssdb.Namespaced(c, "svc:")
+	for _, args := range [][]interface{}{{"flushdb"}, {"compact"}} {
+		if _, err := n.Do(args...); err == nil {
+			t.Errorf("Do(%v) ran in a namespace", args)
+		}
+	}
+	p := n.Pipeline()
+	p.Append("set", "b", "1")
+	p.Append("flushdb")
+	if _, err := p.Exec(); err == nil {
+		t.Error("a pipeline ran flushdb in a namespace")
+	}
+	if err := n.BatchSend([][]interface{}{{"flushdb"}}); err == nil {
+		t.Error("BatchSend ran flushdb in a namespace")
+	}
+	if v, err := c.Get("a"); err != nil || v != "1" {
+		t.Fatalf("raw Get = %q, %v, want the key kept", v, err)
+	}
+}
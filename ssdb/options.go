@@ -0,0 +1,166 @@
+package ssdb
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net"
+	"time"
+)
+
+// Option configures a Client created by Dial.
+type Option func(*options)
+
+// Dialer opens the connections of a client, *net.Dialer is a Dialer.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+type options struct {
+	network      string
+	dialer       Dialer
+	password     string
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	keepAlive    time.Duration
+	healthCheck  time.Duration
+	zip          bool
+	logger       Logger
+	metrics      Metrics
+	hooks        []Hook
+	codec        Codec
+	reconnect    ReconnectPolicy
+	tlsConfig    *tls.Config
+	serverName   string // host name verified over TLS, the host by default
+}
+
+func defaultOptions() options {
+	return options{
+		dialTimeout: 60 * time.Second,
+	}
+}
+
+// newOptions returns the default options with opts applied.
+func newOptions(opts []Option) options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithPassword authenticates the connection with auth.
+func WithPassword(auth string) Option {
+	return func(o *options) {
+		o.password = auth
+	}
+}
+
+// WithDialTimeout sets the timeout of a connect, 60s by default.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = d
+	}
+}
+
+// WithReadTimeout sets the time to wait for a response on the connection,
+// the connection is broken when it expires, 0 means no limit. The deadline
+// of the context of a command only bounds the wait of its caller.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the time to write a command when the context of the
+// command has no deadline, 0 means no limit.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = d
+	}
+}
+
+// WithDialer opens the connections with d, the dial timeout applies to the
+// context passed to d.
+func WithDialer(d Dialer) Option {
+	return func(o *options) {
+		o.dialer = d
+	}
+}
+
+// WithKeepAlive sets the TCP keepalive period, a negative period disables
+// keepalive. It is ignored with WithDialer.
+func WithKeepAlive(d time.Duration) Option {
+	return func(o *options) {
+		o.keepAlive = d
+	}
+}
+
+// WithHealthCheckInterval pings the server every d in the background, see
+// Client.HealthCheck.
+func WithHealthCheckInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.healthCheck = d
+	}
+}
+
+// WithZip sends the commands in the zip envelope.
+func WithZip(flag bool) Option {
+	return func(o *options) {
+		o.zip = flag
+	}
+}
+
+// WithLogger sends the log messages of the client to l. The messages of all
+// levels are passed to l, which filters them, debug mode does not apply.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithDebugLogger logs the messages of the client to l, debug messages
+// included, whether or not debug mode is on.
+func WithDebugLogger(l *log.Logger) Option {
+	return WithLogger(NewStdLogger(l))
+}
+
+// WithMetrics reports the commands, traffic and reconnects of the client to
+// m, see MetricsCollector.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithHooks adds hs to the hooks of the client, see Client.AddHook.
+func WithHooks(hs ...Hook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks[:len(o.hooks):len(o.hooks)], hs...)
+	}
+}
+
+// WithCodec sets the codec of GetAs, SetAs, HashGetAs and HashSetAs,
+// JSONCodec by default.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithReconnectPolicy sets how the client reconnects, see ReconnectPolicy.
+func WithReconnectPolicy(p ReconnectPolicy) Option {
+	return func(o *options) {
+		o.reconnect = p
+	}
+}
+
+// WithTLSConfig connects over TLS with config. The server name is verified
+// against the host of the address unless config sets ServerName, client
+// certificates are set in config.Certificates.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
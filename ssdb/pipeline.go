@@ -0,0 +1,387 @@
+package ssdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+type request struct {
+	ctx   context.Context
+	args  []interface{}
+	reply chan ClientProcessResult
+	start time.Time
+}
+
+func newRequest(ctx context.Context, args []interface{}) *request {
+	return &request{ctx: ctx, args: args, reply: make(chan ClientProcessResult, 1), start: time.Now()}
+}
+
+// aLongTimeAgo is a deadline in the past used to abort blocked socket calls.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// conn is a socket shared by concurrent callers. Writers queue requests in
+// the order they are written and a single reader goroutine hands out the
+// responses in the same order.
+type conn struct {
+	client *Client
+	sock   net.Conn
+	cr     *countReader
+	rd     *proto.Reader
+	wmu    sync.Mutex
+	wbuf   []byte // encoded requests, reused under wmu
+	qmu    sync.Mutex
+	queue  []*request
+	ready  chan struct{}
+	done   chan struct{}
+	err    error
+}
+
+func newConn(c *Client, sock net.Conn) *conn {
+	cn := &conn{
+		client: c,
+		sock:   sock,
+		cr:     &countReader{r: sock},
+		ready:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	cn.rd = proto.NewReader(cn.cr)
+	go cn.readLoop()
+	return cn
+}
+
+// maxWriteBuffer is the largest write buffer kept for the next write.
+const maxWriteBuffer = 64 << 10
+
+// write sends reqs in a single write and queues them for the reader.
+func (cn *conn) write(ctx context.Context, reqs []*request) error {
+	cn.wmu.Lock()
+	defer cn.wmu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := cn.broken(); err != nil {
+		return err
+	}
+	buf := cn.wbuf[:0]
+	for _, req := range reqs {
+		var err error
+		if buf, err = cn.client.encode(buf, req.args); err != nil {
+			return err
+		}
+	}
+	if cap(buf) <= maxWriteBuffer {
+		cn.wbuf = buf
+	}
+	deadline, ok := ctx.Deadline()
+	if wt := cn.client.opts.writeTimeout; !ok && wt > 0 {
+		deadline = time.Now().Add(wt)
+	}
+	cn.sock.SetWriteDeadline(deadline)
+	aborted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		cn.sock.SetWriteDeadline(aLongTimeAgo)
+		close(aborted)
+	})
+	n, err := cn.sock.Write(buf)
+	if !stop() {
+		<-aborted
+	}
+	if m := cn.client.opts.metrics; m != nil {
+		m.ObserveBytesSent(n)
+	}
+	if err != nil {
+		cerr := contextError(ctx, err)
+		if n == 0 && (errors.Is(cerr, context.Canceled) || errors.Is(cerr, context.DeadlineExceeded)) {
+			// Nothing was sent, the stream is intact and only this caller
+			// gives up.
+			return cerr
+		}
+		// A partial write breaks the stream, the queued requests fail with
+		// the socket error and the caller gets the error of its context.
+		cn.fail(err)
+		return cerr
+	}
+
+	cn.qmu.Lock()
+	if cn.err != nil {
+		cn.qmu.Unlock()
+		return cn.err
+	}
+	cn.queue = append(cn.queue, reqs...)
+	cn.qmu.Unlock()
+	select {
+	case cn.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (cn *conn) broken() error {
+	cn.qmu.Lock()
+	defer cn.qmu.Unlock()
+	return cn.err
+}
+
+// fail closes the socket and fails all queued requests with err.
+func (cn *conn) fail(err error) {
+	cn.qmu.Lock()
+	if cn.err != nil {
+		cn.qmu.Unlock()
+		return
+	}
+	cn.err = err
+	pending := cn.queue
+	cn.queue = nil
+	close(cn.done)
+	cn.qmu.Unlock()
+	cn.sock.Close()
+	for _, req := range pending {
+		req.reply <- ClientProcessResult{Error: err}
+	}
+}
+
+// next returns the oldest queued request, it returns nil when the connection
+// is broken.
+func (cn *conn) next() *request {
+	for {
+		cn.qmu.Lock()
+		if len(cn.queue) > 0 {
+			req := cn.queue[0]
+			cn.queue[0] = nil
+			cn.queue = cn.queue[1:]
+			cn.qmu.Unlock()
+			return req
+		}
+		if cn.err != nil {
+			cn.qmu.Unlock()
+			return nil
+		}
+		cn.qmu.Unlock()
+		select {
+		case <-cn.ready:
+		case <-cn.done:
+		}
+	}
+}
+
+func (cn *conn) readLoop() {
+	for {
+		req := cn.next()
+		if req == nil {
+			return
+		}
+		// The deadline of a caller is not applied to the socket: a caller
+		// that gives up stops waiting in req.wait, and its response is still
+		// read and dropped so that the other callers keep their responses.
+		var deadline time.Time
+		if rt := cn.client.opts.readTimeout; rt > 0 {
+			deadline = time.Now().Add(rt)
+		}
+		cn.sock.SetReadDeadline(deadline)
+		resp, err := cn.recv()
+		if m := cn.client.opts.metrics; m != nil {
+			m.ObserveBytesReceived(cn.cr.take())
+			if err == nil && cn.rd.Zipped() {
+				m.ObserveZip(packetSize(resp), zipPacketSize(cn.rd.ZipSize()))
+			}
+		}
+		if err != nil {
+			cn.client.debugLog("receive failed", "client", cn.client.Id, "args", req.args, "err", err)
+			req.reply <- ClientProcessResult{Error: err}
+			cn.client.breakConn(cn, err)
+			cn.fail(err)
+			return
+		}
+		cn.client.debugLog("receive", "client", cn.client.Id, "resp", resp)
+		req.reply <- ClientProcessResult{Data: resp}
+	}
+}
+
+// roundTrip writes reqs on the current connection and waits for their
+// responses, it returns early with the error of ctx when ctx is done.
+func (c *Client) roundTrip(ctx context.Context, reqs []*request) error {
+	cn := c.current()
+	if cn == nil {
+		return ErrClosed
+	}
+	if err := cn.write(ctx, reqs); err != nil {
+		c.debugLog("send failed", "client", c.Id, "err", err)
+		if cn.broken() != nil {
+			c.breakConn(cn, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// wait returns the response of req or the error of ctx.
+func (req *request) wait(ctx context.Context) ([]string, error) {
+	select {
+	case cpr := <-req.reply:
+		return cpr.Data, cpr.Error
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Pipeline queues commands and sends them to the server in a single write,
+// the responses are returned in the order of the commands.
+type Pipeline struct {
+	c    *Client
+	cmds [][]interface{}
+	ns   namespace
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Append queues a command, the arguments are the same as Client.Do.
+func (p *Pipeline) Append(args ...interface{}) {
+	p.cmds = append(p.cmds, args)
+}
+
+// Len returns the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+func (p *Pipeline) Exec() ([][]string, error) {
+	return p.ExecContext(context.Background())
+}
+
+// ExecContext sends the queued commands and returns one response per command.
+// The first element of each response is the status of the command. The error
+// of the first command that failed on the connection is returned.
+func (p *Pipeline) ExecContext(ctx context.Context) ([][]string, error) {
+	args := p.cmds
+	p.cmds = nil
+	if len(args) == 0 {
+		return [][]string{}, nil
+	}
+	ns := namespaceFrom(ctx) + p.ns
+	rules := make([]keyRule, len(args))
+	cmds := make([]*Cmd, len(args))
+	for i, a := range args {
+		if ns != "" {
+			var err error
+			if a, rules[i], err = ns.command(a); err != nil {
+				return nil, err
+			}
+		}
+		cmds[i] = newCmd(a)
+	}
+	err := p.c.getHooks().processPipeline(ctx, cmds, p.c.exec)
+	resps := make([][]string, len(cmds))
+	for i, cmd := range cmds {
+		resps[i] = cmd.Resp
+		if ns != "" {
+			resps[i] = ns.response(rules[i], cmd.Resp)
+		}
+	}
+	return resps, err
+}
+
+// exec sends cmds in a single write and records their responses, it returns
+// the error of the first command that failed on the connection.
+func (c *Client) exec(ctx context.Context, cmds []*Cmd) error {
+	start := time.Now()
+	if !c.alive() {
+		err := connError("pipeline", ErrClosed)
+		for _, cmd := range cmds {
+			cmd.done(nil, err, 0)
+		}
+		return err
+	}
+	if c.route != nil {
+		return c.route.exec(withoutNamespace(ctx), cmds)
+	}
+	reqs := make([]*request, len(cmds))
+	for i, cmd := range cmds {
+		reqs[i] = newRequest(ctx, cmd.args())
+	}
+	if err := c.roundTrip(ctx, reqs); err != nil {
+		err = connError(cmds[0].Name, err)
+		for i, cmd := range cmds {
+			c.observe(reqs[i], nil, err)
+			cmd.done(nil, err, time.Since(start))
+		}
+		return err
+	}
+	var firstErr error
+	for i, req := range reqs {
+		var resp []string
+		err := ctx.Err()
+		if err == nil {
+			resp, err = req.wait(ctx)
+		}
+		c.observe(req, resp, err)
+		if err != nil {
+			err = connError(cmds[i].Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		cmds[i].done(resp, err, 0)
+	}
+	d := time.Since(start)
+	for _, cmd := range cmds {
+		cmd.Duration = d
+	}
+	return firstErr
+}
+
+func (c *Client) MultiMode(args [][]interface{}) ([]string, error) {
+	p := c.Pipeline()
+	for _, v := range args {
+		p.Append(v...)
+	}
+	results, err := p.Exec()
+	if err != nil {
+		c.debugLog("MultiMode failed", "client", c.Id, "args", args, "err", err)
+		return nil, err
+	}
+	var resps []string
+	for _, resp := range results {
+		resps = append(resps, strings.Join(resp, ","))
+	}
+	return resps, nil
+}
+
+// Send writes a command without waiting for its response, the responses are
+// returned by Recv in the order of the commands.
+func (c *Client) Send(args []interface{}) error {
+	if !c.alive() {
+		return ErrClosed
+	}
+	req := newRequest(context.Background(), args)
+	if err := c.roundTrip(context.Background(), []*request{req}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.unread = append(c.unread, req)
+	c.mu.Unlock()
+	return nil
+}
+
+// Recv returns the response of the oldest command written by Send.
+func (c *Client) Recv() ([]string, error) {
+	c.mu.Lock()
+	if len(c.unread) == 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("no command to receive")
+	}
+	req := c.unread[0]
+	c.unread = c.unread[1:]
+	c.mu.Unlock()
+	resp, err := req.wait(context.Background())
+	c.observe(req, resp, err)
+	return resp, err
+}
@@ -0,0 +1,318 @@
+package ssdb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PoolConfig controls how a Pool dials, keeps and recycles connections.
+type PoolConfig struct {
+	// Dial creates a new connection. When nil the pool dials the host,
+	// port and password given to NewPool.
+	Dial func() (*Client, error)
+	// MinIdle is the number of idle connections the pool tries to keep open.
+	MinIdle int
+	// MaxIdle is the maximum number of idle connections kept in the pool.
+	MaxIdle int
+	// MaxActive limits the number of borrowed connections, 0 means no limit.
+	MaxActive int
+	// IdleTimeout closes connections that stay idle longer than this value.
+	IdleTimeout time.Duration
+	// MaxLifetime closes connections older than this value.
+	MaxLifetime time.Duration
+	// TestIdle pings connections idle longer than this value before they are
+	// handed out, 0 pings on every borrow.
+	TestIdle time.Duration
+	// Options are used to dial the connections when Dial is nil, e.g.
+	// WithLogger, WithMetrics, WithHooks or WithCodec. The logger of
+	// WithLogger also receives the log messages of the pool.
+	Options []Option
+}
+
+// Pool is a goroutine-safe pool of Client connections.
+type Pool struct {
+	Ip       string
+	Port     int
+	Password string
+	config   PoolConfig
+	logger   Logger
+	mu       sync.Mutex
+	idle     []*Client
+	sem      chan struct{}
+	closed   bool
+	stop     chan struct{}
+}
+
+const poolReapInterval = 10 * time.Second
+
+func NewPool(host string, port int, auth string, config PoolConfig) (*Pool, error) {
+	if config.MaxIdle <= 0 {
+		config.MaxIdle = 2
+	}
+	if config.MinIdle > config.MaxIdle {
+		config.MinIdle = config.MaxIdle
+	}
+	p := &Pool{
+		Ip:       host,
+		Port:     port,
+		Password: auth,
+		config:   config,
+		logger:   newOptions(config.Options).logger,
+		stop:     make(chan struct{}),
+	}
+	if config.MaxActive > 0 {
+		p.sem = make(chan struct{}, config.MaxActive)
+	}
+	if err := p.fill(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	go p.reaper()
+	return p, nil
+}
+
+func (p *Pool) dial() (*Client, error) {
+	var c *Client
+	var err error
+	if p.config.Dial != nil {
+		c, err = p.config.Dial()
+	} else {
+		opts := append([]Option{WithPassword(p.Password)}, p.config.Options...)
+		c, err = dial(net.JoinHostPort(p.Ip, strconv.Itoa(p.Port)), opts...)
+	}
+	if err != nil {
+		if c != nil {
+			c.Close()
+		}
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("pool dial %s:%d failed", p.Ip, p.Port)
+	}
+	c.pool = p
+	c.created = time.Now()
+	c.used = c.created
+	return c, nil
+}
+
+// Get borrows a connection from the pool, dialing a new one when no idle
+// connection is available. The connection must be returned with Put.
+func (p *Pool) Get(ctx context.Context) (*Client, error) {
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return p.get(ctx)
+}
+
+// tryGet is Get without waiting for a free slot, it returns nil when
+// MaxActive connections are borrowed.
+func (p *Pool) tryGet() (*Client, error) {
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+		default:
+			return nil, nil
+		}
+	}
+	return p.get(context.Background())
+}
+
+// get hands out an idle connection or dials one, the caller holds a slot.
+func (p *Pool) get(ctx context.Context) (*Client, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			p.release()
+			return nil, fmt.Errorf("pool has closed")
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.mu.Unlock()
+			break
+		}
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+
+		if p.expired(c, time.Now()) || !c.alive() {
+			c.Close()
+			continue
+		}
+		if time.Since(c.used) >= p.config.TestIdle {
+			if err := c.Ping(); err != nil {
+				c.Close()
+				continue
+			}
+		}
+		return c, nil
+	}
+	if err := ctx.Err(); err != nil {
+		p.release()
+		return nil, err
+	}
+	c, err := p.dial()
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Put returns a connection borrowed with Get to the pool.
+func (p *Pool) Put(c *Client) {
+	if c == nil {
+		return
+	}
+	defer p.release()
+	c.used = time.Now()
+	p.mu.Lock()
+	if p.closed || !c.alive() || p.expired(c, c.used) || len(p.idle) >= p.config.MaxIdle {
+		p.mu.Unlock()
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+// Do borrows a connection, runs the command on it and returns it to the pool.
+func (p *Pool) Do(args ...interface{}) ([]string, error) {
+	c, err := p.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer p.Put(c)
+	return c.Do(args...)
+}
+
+// Len returns the number of idle connections.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Close closes all idle connections, borrowed connections are closed when
+// they are returned.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+	close(p.stop)
+	for _, c := range idle {
+		c.Close()
+	}
+	return nil
+}
+
+func (p *Pool) release() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
+
+func (p *Pool) expired(c *Client, now time.Time) bool {
+	if p.config.IdleTimeout > 0 && now.Sub(c.used) > p.config.IdleTimeout {
+		return true
+	}
+	if p.config.MaxLifetime > 0 && now.Sub(c.created) > p.config.MaxLifetime {
+		return true
+	}
+	return false
+}
+
+// fill dials connections until MinIdle idle connections are available.
+func (p *Pool) fill() error {
+	for {
+		p.mu.Lock()
+		need := !p.closed && len(p.idle) < p.config.MinIdle
+		p.mu.Unlock()
+		if !need {
+			return nil
+		}
+		c, err := p.dial()
+		if err != nil {
+			return err
+		}
+		p.mu.Lock()
+		if p.closed || len(p.idle) >= p.config.MaxIdle {
+			p.mu.Unlock()
+			c.Close()
+			return nil
+		}
+		p.idle = append(p.idle, c)
+		p.mu.Unlock()
+	}
+}
+
+func (p *Pool) reaper() {
+	ticker := time.NewTicker(poolReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			var stale []*Client
+			p.mu.Lock()
+			live := p.idle[:0]
+			for _, c := range p.idle {
+				if p.expired(c, now) || !c.alive() {
+					stale = append(stale, c)
+				} else {
+					live = append(live, c)
+				}
+			}
+			p.idle = live
+			p.mu.Unlock()
+			for _, c := range stale {
+				c.Close()
+			}
+			if err := p.fill(); err != nil && p.logger != nil {
+				p.logger.Warn("pool fill failed", "host", p.Ip, "port", p.Port, "err", err)
+			}
+		}
+	}
+}
+
+// borrow returns another connection to the server of c and a function that
+// releases it. Connections created by a Pool borrow from that pool, other
+// connections dial a private connection that is closed on release. A routed
+// client is its own connection, and so is a pooled client when the pool has
+// no free slot, its commands are pipelined on the same socket.
+func (c *Client) borrow() (*Client, func(), error) {
+	if c.route != nil {
+		return c, func() {}, nil
+	}
+	if c.pool != nil {
+		inner, err := c.pool.tryGet()
+		if err != nil {
+			return nil, nil, err
+		}
+		if inner == nil {
+			return c, func() {}, nil
+		}
+		return inner, func() { c.pool.Put(inner) }, nil
+	}
+	inner, err := c.clone()
+	if err != nil {
+		inner.Close()
+		return nil, nil, err
+	}
+	return inner, func() { inner.Close() }, nil
+}
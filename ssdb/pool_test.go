@@ -0,0 +1,148 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// newPool returns a pool of connections to srv closed at the end of the
+// test, and the number of connections it dialed.
+func newPool(t *testing.T, srv *ssdbtest.Server, config ssdb.PoolConfig) (*ssdb.Pool, *atomic.Int32) {
+	t.Helper()
+	var dials atomic.Int32
+	config.Dial = func() (*ssdb.Client, error) {
+		dials.Add(1)
+		return ssdb.Dial(srv.Addr)
+	}
+	p, err := ssdb.NewPool(srv.Host(), srv.Port(), "", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, &dials
+}
+
+func get(t *testing.T, p *ssdb.Pool) *ssdb.Client {
+	t.Helper()
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPoolGetPut(t *testing.T) {
+	srv := newServer(t)
+	p, dials := newPool(t, srv, ssdb.PoolConfig{})
+	c := get(t, p)
+	testKV(t, c)
+	p.Put(c)
+	if n := p.Len(); n != 1 {
+		t.Fatalf("Len = %d, want 1", n)
+	}
+	if got := get(t, p); got != c {
+		t.Fatal("Get dialed while a connection was idle")
+	}
+	if n := dials.Load(); n != 1 {
+		t.Fatalf("dials = %d, want 1", n)
+	}
+}
+
+func TestPoolMaxActive(t *testing.T) {
+	srv := newServer(t)
+	p, _ := newPool(t, srv, ssdb.PoolConfig{MaxActive: 1})
+	c := get(t, p)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get past MaxActive: %v, want a deadline error", err)
+	}
+	p.Put(c)
+	p.Put(get(t, p))
+}
+
+func TestPoolIdleTimeout(t *testing.T) {
+	srv := newServer(t)
+	p, dials := newPool(t, srv, ssdb.PoolConfig{IdleTimeout: 10 * time.Millisecond})
+	c := get(t, p)
+	p.Put(c)
+	time.Sleep(20 * time.Millisecond)
+	if got := get(t, p); got == c {
+		t.Fatal("Get handed out a connection idle past IdleTimeout")
+	}
+	if n := dials.Load(); n != 2 {
+		t.Fatalf("dials = %d, want 2", n)
+	}
+}
+
+func TestPoolMaxLifetime(t *testing.T) {
+	srv := newServer(t)
+	p, _ := newPool(t, srv, ssdb.PoolConfig{MaxLifetime: 10 * time.Millisecond})
+	c := get(t, p)
+	time.Sleep(20 * time.Millisecond)
+	p.Put(c)
+	if n := p.Len(); n != 0 {
+		t.Fatalf("Len = %d, want the old connection closed", n)
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	srv := newServer(t)
+	p, dials := newPool(t, srv, ssdb.PoolConfig{})
+	c := get(t, p)
+	p.Put(c)
+	srv.AddFault("ping", ssdbtest.Fault{Response: []string{"error", "server busy"}, Times: 1})
+	got := get(t, p)
+	if got == c {
+		t.Fatal("Get handed out a connection that failed its ping")
+	}
+	if n := dials.Load(); n != 2 {
+		t.Fatalf("dials = %d, want 2", n)
+	}
+	testKV(t, got)
+}
+
+// TestPoolBorrow runs the helpers that borrow more connections on a pool
+// without a free slot, they share the connection instead of waiting.
+func TestPoolBorrow(t *testing.T) {
+	srv := newServer(t)
+	p, _ := newPool(t, srv, ssdb.PoolConfig{MaxActive: 1})
+	c := get(t, p)
+	defer p.Put(c)
+
+	var parts []ssdb.HashData
+	var batch [][]interface{}
+	for i := 0; i < 10; i++ {
+		parts = append(parts, ssdb.HashData{HashName: "h", Key: fmt.Sprint(i), Value: "v"})
+		batch = append(batch, []interface{}{"set", fmt.Sprint(i), "v"})
+	}
+	done := make(chan error, 1)
+	go func() {
+		if _, err := c.MultiHashSet(parts, 4); err != nil {
+			done <- err
+			return
+		}
+		done <- c.BatchSend(batch)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("MultiHashSet and BatchSend blocked on a pool without a free slot")
+	}
+	if n, err := c.HashSize("h"); err != nil || n != 10 {
+		t.Fatalf("HashSize = %d, %v, want 10", n, err)
+	}
+	if v, err := c.Get("9"); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v, want v", v, err)
+	}
+}
@@ -0,0 +1,172 @@
+package proto_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+// The benchmarks compare the Reader and Writer with the parser and encoder
+// used by the client before them:
+//
+//	go test -bench . -benchmem ./ssdb/proto
+
+const (
+	benchBlocks = 20 // blocks per packet
+	benchSize   = 64 // bytes per block
+)
+
+func benchArgs() []interface{} {
+	args := []interface{}{"multi_hset", "hash"}
+	for i := 0; i < benchBlocks/2; i++ {
+		args = append(args, fmt.Sprintf("key%d", i), strings.Repeat("v", benchSize))
+	}
+	return args
+}
+
+// benchStream returns a stream of responses.
+func benchStream(b *testing.B) []byte {
+	resp := []string{"ok"}
+	for i := 1; i < benchBlocks; i++ {
+		resp = append(resp, strings.Repeat("v", benchSize))
+	}
+	var packet bytes.Buffer
+	w := proto.NewWriter(&packet)
+	if err := w.WriteStrings(resp...); err != nil {
+		b.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	return bytes.Repeat(packet.Bytes(), 1000)
+}
+
+func BenchmarkEncodeLegacy(b *testing.B) {
+	args := benchArgs()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyEncode(args)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	args := benchArgs()
+	b.ReportAllocs()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		buf, _ = proto.AppendCommand(buf[:0], args...)
+	}
+}
+
+func BenchmarkParseLegacy(b *testing.B) {
+	r := &repeatReader{data: benchStream(b)}
+	var l legacyParser
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := l.recv(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	r := proto.NewReader(&repeatReader{data: benchStream(b)})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.ReadStrings(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// repeatReader returns data over and over in chunks like a socket.
+type repeatReader struct {
+	data []byte
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.off == len(r.data) {
+		r.off = 0
+	}
+	if len(p) > 4096 {
+		p = p[:4096]
+	}
+	n := copy(p, r.data[r.off:])
+	r.off += n
+	return n, nil
+}
+
+func legacyEncode(args []interface{}) []byte {
+	var buf bytes.Buffer
+	for _, arg := range args {
+		s := arg.(string)
+		buf.WriteString(fmt.Sprintf("%d", len(s)))
+		buf.WriteByte('\n')
+		buf.WriteString(s)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// legacyParser is the parser of the client before package proto.
+type legacyParser struct {
+	recv_buf bytes.Buffer
+}
+
+func (c *legacyParser) recv(r io.Reader) ([]string, error) {
+	var tmp [102400]byte
+	for {
+		resp := c.parse()
+		if resp == nil || len(resp) > 0 {
+			return resp, nil
+		}
+		n, err := r.Read(tmp[0:])
+		if err != nil {
+			return nil, err
+		}
+		c.recv_buf.Write(tmp[0:n])
+	}
+}
+
+func (c *legacyParser) parse() []string {
+	resp := []string{}
+	buf := c.recv_buf.Bytes()
+	var Idx, offset int
+	for {
+		Idx = bytes.IndexByte(buf[offset:], '\n')
+		if Idx == -1 {
+			break
+		}
+		p := buf[offset : offset+Idx]
+		offset += Idx + 1
+		if len(p) == 0 || (len(p) == 1 && p[0] == '\r') {
+			if len(resp) == 0 {
+				continue
+			} else {
+				c.recv_buf.Next(offset)
+				return resp
+			}
+		}
+		pIdx := strings.Replace(strconv.Quote(string(p)), `"`, ``, -1)
+		size, err := strconv.Atoi(pIdx)
+		if err != nil || size < 0 {
+			return nil
+		}
+		if offset+size >= c.recv_buf.Len() {
+			break
+		}
+		v := buf[offset : offset+size]
+		resp = append(resp, string(v))
+		offset += size + 1
+	}
+	return []string{}
+}
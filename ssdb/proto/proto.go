@@ -0,0 +1,211 @@
+// Package proto implements the wire format of SSDB.
+//
+// A packet is a list of blocks followed by an empty line, a block is its
+// length in decimal, a newline, the data and a newline:
+//
+//	3
+//	get
+//	1
+//	a
+//
+// A packet whose first block is "zip" carries the blocks of the real packet
+// gzip compressed and base64 encoded in its second block.
+package proto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DefaultMaxPacketSize is the default limit of the data of a packet read by a
+// Reader.
+const DefaultMaxPacketSize = 64 << 20
+
+// ErrPacketTooLarge is returned by a Reader when a packet is larger than its
+// MaxPacketSize.
+var ErrPacketTooLarge = errors.New("proto: packet too large")
+
+// ErrProtocol is returned when the data read is not in the SSDB format, the
+// stream can not be read any further.
+var ErrProtocol = errors.New("proto: bad packet")
+
+var zipBlock = []byte("zip")
+
+// AppendArg appends arg as a block to dst. Supported types are string,
+// []byte, int, int64, float64, bool and nil, the elements of a []string or
+// []interface{} are appended as separate blocks.
+func AppendArg(dst []byte, arg interface{}) ([]byte, error) {
+	switch arg := arg.(type) {
+	case string:
+		return appendString(dst, arg), nil
+	case []byte:
+		dst = strconv.AppendInt(dst, int64(len(arg)), 10)
+		dst = append(dst, '\n')
+		dst = append(dst, arg...)
+	case []string:
+		for _, s := range arg {
+			dst = appendString(dst, s)
+		}
+		return dst, nil
+	case []interface{}:
+		var err error
+		for _, v := range arg {
+			if dst, err = AppendArg(dst, v); err != nil {
+				return dst, err
+			}
+		}
+		return dst, nil
+	case int:
+		return appendNumber(dst, strconv.AppendInt(nil, int64(arg), 10)), nil
+	case int64:
+		return appendNumber(dst, strconv.AppendInt(nil, arg, 10)), nil
+	case float64:
+		return appendNumber(dst, strconv.AppendFloat(nil, arg, 'f', 6, 64)), nil
+	case bool:
+		if arg {
+			dst = append(dst, "1\n1"...)
+		} else {
+			dst = append(dst, "1\n0"...)
+		}
+	case nil:
+		dst = append(dst, "0\n"...)
+	default:
+		return dst, fmt.Errorf("proto: bad argument type %T", arg)
+	}
+	return append(dst, '\n'), nil
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst = strconv.AppendInt(dst, int64(len(s)), 10)
+	dst = append(dst, '\n')
+	dst = append(dst, s...)
+	return append(dst, '\n')
+}
+
+func appendNumber(dst []byte, num []byte) []byte {
+	dst = strconv.AppendInt(dst, int64(len(num)), 10)
+	dst = append(dst, '\n')
+	dst = append(dst, num...)
+	return append(dst, '\n')
+}
+
+// AppendCommand appends the packet of a command to dst, the arguments are
+// converted as in AppendArg. dst is returned unchanged on error.
+func AppendCommand(dst []byte, args ...interface{}) ([]byte, error) {
+	n := len(dst)
+	for _, arg := range args {
+		var err error
+		if dst, err = AppendArg(dst, arg); err != nil {
+			return dst[:n], err
+		}
+	}
+	return append(dst, '\n'), nil
+}
+
+// AppendZipCommand appends the packet of a command in a zip envelope to dst.
+func AppendZipCommand(dst []byte, args ...interface{}) ([]byte, error) {
+	var blocks []byte
+	for _, arg := range args {
+		var err error
+		if blocks, err = AppendArg(blocks, arg); err != nil {
+			return dst, err
+		}
+	}
+	dst = append(dst, "3\nzip\n"...)
+	dst = appendNumber(dst, Zip(blocks))
+	return append(dst, '\n'), nil
+}
+
+// Zip compresses data with gzip and encodes it in base64, the format of the
+// second block of a zip packet.
+func Zip(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	dst := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(dst, buf.Bytes())
+	return dst
+}
+
+// Unzip reverses Zip.
+func Unzip(data []byte) ([]byte, error) {
+	return unzip(nil, data, 0)
+}
+
+// unzip appends the data of a zip block to dst, at most max bytes when max is
+// positive.
+func unzip(dst []byte, data []byte, max int) ([]byte, error) {
+	raw := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(raw, data)
+	if err != nil {
+		return dst, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw[:n]))
+	if err != nil {
+		return dst, err
+	}
+	defer zr.Close()
+	buf := bytes.NewBuffer(dst)
+	var r io.Reader = zr
+	if max > 0 {
+		r = io.LimitReader(zr, int64(max)+1)
+	}
+	if _, err := buf.ReadFrom(r); err != nil {
+		return buf.Bytes(), err
+	}
+	if max > 0 && buf.Len()-len(dst) > max {
+		return buf.Bytes(), ErrPacketTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseBlocks splits data into blocks, it stops at an empty line or at the
+// end of data. The blocks share the memory of data. The newline after the
+// data of a block is optional, as in the zip envelope of some servers.
+func ParseBlocks(data []byte) ([][]byte, error) {
+	return parseBlocks(nil, data)
+}
+
+func parseBlocks(blocks [][]byte, data []byte) ([][]byte, error) {
+	for len(data) > 0 && data[0] != '\n' {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			return blocks, ErrProtocol
+		}
+		size, ok := parseSize(data[:i])
+		if !ok || size > len(data)-i-1 {
+			return blocks, ErrProtocol
+		}
+		data = data[i+1:]
+		blocks = append(blocks, data[:size])
+		data = data[size:]
+		if len(data) > 0 && data[0] == '\n' {
+			data = data[1:]
+		}
+	}
+	return blocks, nil
+}
+
+// parseSize parses the length line of a block, a trailing '\r' is ignored.
+func parseSize(line []byte) (int, bool) {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	if len(line) == 0 || len(line) > 10 {
+		return 0, false
+	}
+	size := 0
+	for _, b := range line {
+		if b < '0' || b > '9' {
+			return 0, false
+		}
+		size = size*10 + int(b-'0')
+	}
+	return size, true
+}
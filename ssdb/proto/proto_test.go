@@ -0,0 +1,173 @@
+package proto_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+func read(t *testing.T, data string, max int) ([]string, error) {
+	t.Helper()
+	r := proto.NewReader(strings.NewReader(data))
+	r.MaxPacketSize = max
+	return r.ReadStrings()
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+	}{
+		{"single", []string{"ok"}},
+		{"empty value", []string{"ok", ""}},
+		{"binary", []string{"ok", "\x00\xff\x01\n"}},
+		{"crlf", []string{"ok", "a\r\nb\r\n", "\r\n"}},
+		{"large", []string{"ok", strings.Repeat("v", 1<<16)}},
+	}
+	writes := []struct {
+		name  string
+		write func(w *proto.Writer, blocks []string) error
+		zip   bool
+	}{
+		{"WriteStrings", func(w *proto.Writer, blocks []string) error { return w.WriteStrings(blocks...) }, false},
+		{"WriteCommand", func(w *proto.Writer, blocks []string) error { return w.WriteCommand(blocks) }, false},
+		{"WriteZipCommand", func(w *proto.Writer, blocks []string) error { return w.WriteZipCommand(blocks) }, true},
+	}
+	for _, tt := range tests {
+		for _, wt := range writes {
+			t.Run(tt.name+"/"+wt.name, func(t *testing.T) {
+				var buf bytes.Buffer
+				w := proto.NewWriter(&buf)
+				// Two packets, the second one must start where the first
+				// one ended.
+				for i := 0; i < 2; i++ {
+					if err := wt.write(w, tt.blocks); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := w.Flush(); err != nil {
+					t.Fatal(err)
+				}
+				r := proto.NewReader(&buf)
+				for i := 0; i < 2; i++ {
+					got, err := r.ReadStrings()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !reflect.DeepEqual(got, tt.blocks) {
+						t.Fatalf("ReadStrings = %q, want %q", got, tt.blocks)
+					}
+					if r.Zipped() != wt.zip {
+						t.Fatalf("Zipped = %v, want %v", r.Zipped(), wt.zip)
+					}
+				}
+				if _, err := r.ReadStrings(); err != io.EOF {
+					t.Fatalf("ReadStrings at the end: %v, want io.EOF", err)
+				}
+			})
+		}
+	}
+}
+
+func TestTerminators(t *testing.T) {
+	want := []string{"ok", "1"}
+	for _, data := range []string{
+		"2\nok\n1\n1\n\n",
+		"2\r\nok\r\n1\r\n1\r\n\r\n",
+		"2\r\nok\n1\n1\r\n\n",
+		"\n\r\n2\nok\n1\n1\n\n",
+	} {
+		got, err := read(t, data, 0)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("read %q = %q, %v, want %q", data, got, err, want)
+		}
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	tests := []struct {
+		data string
+		err  error
+	}{
+		{"x\nok\n\n", proto.ErrProtocol},
+		{"-1\nok\n\n", proto.ErrProtocol},
+		{"2 \nok\n\n", proto.ErrProtocol},
+		{"99999999999\nok\n\n", proto.ErrProtocol},
+		{"2\nokk\n\n", proto.ErrProtocol},
+		{"2\nok\rx\n", proto.ErrProtocol},
+		{"3\nok", io.ErrUnexpectedEOF},
+		{"2\nok\n", io.ErrUnexpectedEOF},
+		{"3\nzip\n5\n!!!!!\n\n", proto.ErrProtocol},
+	}
+	for _, tt := range tests {
+		if _, err := read(t, tt.data, 0); !errors.Is(err, tt.err) {
+			t.Errorf("read %q: %v, want %v", tt.data, err, tt.err)
+		}
+	}
+}
+
+func TestMaxPacketSize(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"at the limit", "2\nok\n8\n12345678\n\n", nil},
+		{"length header", "11\n", proto.ErrPacketTooLarge},
+		{"total", "2\nok\n9\n123456789\n\n", proto.ErrPacketTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := read(t, tt.data, 10); err != tt.err {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestZipBomb(t *testing.T) {
+	// A few kilobytes of compressed data expanding to 16MB.
+	packet, err := proto.AppendZipCommand(nil, "ok", strings.Repeat("a", 16<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packet) > 1<<16 {
+		t.Fatalf("compressed packet of %d bytes", len(packet))
+	}
+	if _, err := read(t, string(packet), 1<<20); err != proto.ErrPacketTooLarge {
+		t.Fatalf("read: %v, want ErrPacketTooLarge", err)
+	}
+	if got, err := read(t, string(packet), 32<<20); err != nil || len(got) != 2 {
+		t.Fatalf("read under the limit: %d blocks, %v", len(got), err)
+	}
+}
+
+func TestWriterReuse(t *testing.T) {
+	w := proto.NewWriter(io.Discard)
+	args := []interface{}{"set", "key", strings.Repeat("v", 100)}
+	allocs := testing.AllocsPerRun(100, func() {
+		w.WriteCommand(args...)
+		w.WriteStrings("ok", "1")
+		w.Flush()
+	})
+	if allocs != 0 {
+		t.Fatalf("%v allocations per write, want the buffer of the writer reused", allocs)
+	}
+}
+
+func TestAppendCommandReuse(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	got, err := proto.AppendCommand(buf, "get", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "3\nget\n1\na\n\n" || &got[0] != &buf[:1][0] {
+		t.Fatalf("AppendCommand = %q in a new array", got)
+	}
+	if bad, err := proto.AppendCommand(got, "get", struct{}{}); err == nil || string(bad) != string(got) {
+		t.Fatalf("AppendCommand of a bad argument = %q, %v, want dst unchanged", bad, err)
+	}
+}
@@ -0,0 +1,180 @@
+package proto
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Reader reads packets from a buffered stream. The buffers of a Reader are
+// reused, the blocks returned by ReadPacket are only valid until the next
+// call.
+type Reader struct {
+	// MaxPacketSize is the limit of the data of a packet, after
+	// decompression for a zip packet. Zero means no limit.
+	MaxPacketSize int
+
+	r       *bufio.Reader
+	zipped  bool
+	zipSize int
+	buf     []byte
+	zbuf    []byte
+	ends    []int
+	blocks  [][]byte
+}
+
+// NewReader returns a Reader on r with DefaultMaxPacketSize, r is used as is
+// when it is a *bufio.Reader.
+func NewReader(r io.Reader) *Reader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Reader{MaxPacketSize: DefaultMaxPacketSize, r: br}
+}
+
+// ReadPacket reads the next packet and returns its blocks, a zip packet is
+// unpacked. Empty lines before a packet are skipped.
+func (r *Reader) ReadPacket() ([][]byte, error) {
+	r.buf = r.buf[:0]
+	r.ends = r.ends[:0]
+	r.zipped = false
+	r.zipSize = 0
+	for {
+		line, err := r.r.ReadSlice('\n')
+		if err != nil {
+			if err == bufio.ErrBufferFull {
+				return nil, ErrProtocol
+			}
+			if err == io.EOF && (len(r.ends) > 0 || len(line) > 0) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = line[:len(line)-1]
+		if len(line) == 0 || (len(line) == 1 && line[0] == '\r') {
+			if len(r.ends) == 0 {
+				continue
+			}
+			break
+		}
+		size, ok := parseSize(line)
+		if !ok {
+			return nil, ErrProtocol
+		}
+		if r.MaxPacketSize > 0 && size > r.MaxPacketSize-len(r.buf) {
+			return nil, ErrPacketTooLarge
+		}
+		start := len(r.buf)
+		r.buf = grow(r.buf, size)
+		if _, err := io.ReadFull(r.r, r.buf[start:]); err != nil {
+			return nil, noEOF(err)
+		}
+		r.ends = append(r.ends, len(r.buf))
+		if err := r.readEnd(); err != nil {
+			return nil, err
+		}
+	}
+	r.blocks = r.blocks[:0]
+	start := 0
+	for _, end := range r.ends {
+		r.blocks = append(r.blocks, r.buf[start:end])
+		start = end
+	}
+	if len(r.blocks) == 2 && bytes.Equal(r.blocks[0], zipBlock) {
+		r.zipped = true
+		r.zipSize = len(r.blocks[1])
+		return r.unzip(r.blocks[1])
+	}
+	return r.blocks, nil
+}
+
+// ReadStrings reads the next packet as strings. The strings share a single
+// allocation.
+func (r *Reader) ReadStrings() ([]string, error) {
+	blocks, err := r.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, b := range blocks {
+		n += len(b)
+	}
+	var sb strings.Builder
+	sb.Grow(n)
+	for _, b := range blocks {
+		sb.Write(b)
+	}
+	s := sb.String()
+	resp := make([]string, len(blocks))
+	for i, b := range blocks {
+		resp[i] = s[:len(b)]
+		s = s[len(b):]
+	}
+	return resp, nil
+}
+
+// Zipped reports whether the last packet read was in a zip envelope.
+func (r *Reader) Zipped() bool {
+	return r.zipped
+}
+
+// ZipSize returns the size of the compressed data of the last packet, 0 when
+// it was not in a zip envelope.
+func (r *Reader) ZipSize() int {
+	return r.zipSize
+}
+
+// Buffered returns the number of bytes that can be read without blocking.
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// readEnd reads the newline after the data of a block.
+func (r *Reader) readEnd() error {
+	b, err := r.r.ReadByte()
+	if err == nil && b == '\r' {
+		b, err = r.r.ReadByte()
+	}
+	if err != nil {
+		return noEOF(err)
+	}
+	if b != '\n' {
+		return ErrProtocol
+	}
+	return nil
+}
+
+func (r *Reader) unzip(data []byte) ([][]byte, error) {
+	var err error
+	r.zbuf, err = unzip(r.zbuf[:0], data, r.MaxPacketSize)
+	if err != nil {
+		if err == ErrPacketTooLarge {
+			return nil, err
+		}
+		return nil, ErrProtocol
+	}
+	r.blocks, err = parseBlocks(r.blocks[:0], r.zbuf)
+	if err != nil {
+		return nil, err
+	}
+	return r.blocks, nil
+}
+
+// grow extends b by n bytes.
+func grow(b []byte, n int) []byte {
+	if cap(b)-len(b) < n {
+		nb := make([]byte, len(b), 2*cap(b)+n)
+		copy(nb, b)
+		b = nb
+	}
+	return b[:len(b)+n]
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
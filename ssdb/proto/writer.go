@@ -0,0 +1,57 @@
+package proto
+
+import (
+	"bufio"
+	"io"
+)
+
+// Writer writes packets to a buffered stream. Packets are kept in the buffer
+// until Flush is called.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a Writer on w, w is used as is when it is a
+// *bufio.Writer.
+func NewWriter(w io.Writer) *Writer {
+	bw, ok := w.(*bufio.Writer)
+	if !ok {
+		bw = bufio.NewWriter(w)
+	}
+	return &Writer{w: bw}
+}
+
+// WriteCommand writes the packet of a command, see AppendCommand.
+func (w *Writer) WriteCommand(args ...interface{}) error {
+	buf, err := AppendCommand(w.w.AvailableBuffer(), args...)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(buf)
+	return err
+}
+
+// WriteZipCommand writes the packet of a command in a zip envelope.
+func (w *Writer) WriteZipCommand(args ...interface{}) error {
+	buf, err := AppendZipCommand(w.w.AvailableBuffer(), args...)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(buf)
+	return err
+}
+
+// WriteStrings writes a packet of blocks, as a response of a server.
+func (w *Writer) WriteStrings(blocks ...string) error {
+	buf := w.w.AvailableBuffer()
+	for _, b := range blocks {
+		buf = appendString(buf, b)
+	}
+	_, err := w.w.Write(append(buf, '\n'))
+	return err
+}
+
+// Flush writes the buffered packets to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,182 @@
+package ssdb
+
+import (
+	"context"
+)
+
+func queueParams(name string, items []string) []interface{} {
+	params := []interface{}{name}
+	for _, v := range items {
+		params = append(params, v)
+	}
+	return params
+}
+
+// push items to the front of the queue and return the new size of the queue
+func (c *Client) QueuePushFront(name string, items ...string) (int64, error) {
+	return c.QueuePushFrontContext(context.Background(), name, items...)
+}
+
+func (c *Client) QueuePushFrontContext(ctx context.Context, name string, items ...string) (int64, error) {
+	data, err := c.call(ctx, "qpush_front", queueParams(name, items)...)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// push items to the back of the queue and return the new size of the queue
+func (c *Client) QueuePushBack(name string, items ...string) (int64, error) {
+	return c.QueuePushBackContext(context.Background(), name, items...)
+}
+
+func (c *Client) QueuePushBackContext(ctx context.Context, name string, items ...string) (int64, error) {
+	data, err := c.call(ctx, "qpush_back", queueParams(name, items)...)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// remove and return up to size items from the front of the queue
+func (c *Client) QueuePopFront(name string, size int) ([]string, error) {
+	return c.QueuePopFrontContext(context.Background(), name, size)
+}
+
+func (c *Client) QueuePopFrontContext(ctx context.Context, name string, size int) ([]string, error) {
+	return c.call(ctx, "qpop_front", name, size)
+}
+
+// remove and return up to size items from the back of the queue
+func (c *Client) QueuePopBack(name string, size int) ([]string, error) {
+	return c.QueuePopBackContext(context.Background(), name, size)
+}
+
+func (c *Client) QueuePopBackContext(ctx context.Context, name string, size int) ([]string, error) {
+	return c.call(ctx, "qpop_back", name, size)
+}
+
+func (c *Client) QueueFront(name string) (string, error) {
+	return c.QueueFrontContext(context.Background(), name)
+}
+
+func (c *Client) QueueFrontContext(ctx context.Context, name string) (string, error) {
+	data, err := c.call(ctx, "qfront", name)
+	if err != nil {
+		return "", err
+	}
+	return parseString(data)
+}
+
+func (c *Client) QueueBack(name string) (string, error) {
+	return c.QueueBackContext(context.Background(), name)
+}
+
+func (c *Client) QueueBackContext(ctx context.Context, name string) (string, error) {
+	data, err := c.call(ctx, "qback", name)
+	if err != nil {
+		return "", err
+	}
+	return parseString(data)
+}
+
+func (c *Client) QueueSize(name string) (int64, error) {
+	return c.QueueSizeContext(context.Background(), name)
+}
+
+func (c *Client) QueueSizeContext(ctx context.Context, name string) (int64, error) {
+	data, err := c.call(ctx, "qsize", name)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// get the item at index, a negative index counts from the back
+func (c *Client) QueueGet(name string, index int64) (string, error) {
+	return c.QueueGetContext(context.Background(), name, index)
+}
+
+func (c *Client) QueueGetContext(ctx context.Context, name string, index int64) (string, error) {
+	data, err := c.call(ctx, "qget", name, index)
+	if err != nil {
+		return "", err
+	}
+	return parseString(data)
+}
+
+// replace the item at index, a negative index counts from the back
+func (c *Client) QueueSet(name string, index int64, val string) error {
+	return c.QueueSetContext(context.Background(), name, index, val)
+}
+
+func (c *Client) QueueSetContext(ctx context.Context, name string, index int64, val string) error {
+	_, err := c.call(ctx, "qset", name, index, val)
+	return err
+}
+
+// get limit items from offset
+func (c *Client) QueueRange(name string, offset int, limit int) ([]string, error) {
+	return c.QueueRangeContext(context.Background(), name, offset, limit)
+}
+
+func (c *Client) QueueRangeContext(ctx context.Context, name string, offset int, limit int) ([]string, error) {
+	return c.call(ctx, "qrange", name, offset, limit)
+}
+
+// get the items in [begin,end], negative indexes count from the back
+func (c *Client) QueueSlice(name string, begin int, end int) ([]string, error) {
+	return c.QueueSliceContext(context.Background(), name, begin, end)
+}
+
+func (c *Client) QueueSliceContext(ctx context.Context, name string, begin int, end int) ([]string, error) {
+	return c.call(ctx, "qslice", name, begin, end)
+}
+
+// remove up to size items from the front of the queue and return the number of removed items
+func (c *Client) QueueTrimFront(name string, size int) (int64, error) {
+	return c.QueueTrimFrontContext(context.Background(), name, size)
+}
+
+func (c *Client) QueueTrimFrontContext(ctx context.Context, name string, size int) (int64, error) {
+	data, err := c.call(ctx, "qtrim_front", name, size)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// remove up to size items from the back of the queue and return the number of removed items
+func (c *Client) QueueTrimBack(name string, size int) (int64, error) {
+	return c.QueueTrimBackContext(context.Background(), name, size)
+}
+
+func (c *Client) QueueTrimBackContext(ctx context.Context, name string, size int) (int64, error) {
+	data, err := c.call(ctx, "qtrim_back", name, size)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// remove all items of the queue
+func (c *Client) QueueClear(name string) (int64, error) {
+	return c.QueueClearContext(context.Background(), name)
+}
+
+func (c *Client) QueueClearContext(ctx context.Context, name string) (int64, error) {
+	data, err := c.call(ctx, "qclear", name)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// search from start to end queue name,except start word
+func (c *Client) QueueList(start string, end string, limit int) ([]string, error) {
+	return c.QueueListContext(context.Background(), start, end, limit)
+}
+
+func (c *Client) QueueListContext(ctx context.Context, start string, end string, limit int) ([]string, error) {
+	return c.call(ctx, "qlist", start, end, limit)
+}
@@ -0,0 +1,146 @@
+package ssdb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+)
+
+func checkItems(t *testing.T, what string, got []string, err error, want ...string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if len(got) != len(want) || len(want) > 0 && !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s = %q, want %q", what, got, want)
+	}
+}
+
+func TestQueuePushPop(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if n, err := c.QueuePushBack("q", "c", "d"); err != nil || n != 2 {
+		t.Fatalf("QueuePushBack = %d, %v, want 2", n, err)
+	}
+	// The items pushed to the front end in the reverse order.
+	if n, err := c.QueuePushFront("q", "b", "a"); err != nil || n != 4 {
+		t.Fatalf("QueuePushFront = %d, %v, want 4", n, err)
+	}
+	if n, err := c.QueuePushBack("q", "e"); err != nil || n != 5 {
+		t.Fatalf("QueuePushBack = %d, %v, want 5", n, err)
+	}
+	got, err := c.QueueRange("q", 0, -1)
+	checkItems(t, "QueueRange", got, err, "a", "b", "c", "d", "e")
+	if v, err := c.QueueFront("q"); err != nil || v != "a" {
+		t.Fatalf("QueueFront = %q, %v", v, err)
+	}
+	if v, err := c.QueueBack("q"); err != nil || v != "e" {
+		t.Fatalf("QueueBack = %q, %v", v, err)
+	}
+
+	got, err = c.QueuePopFront("q", 2)
+	checkItems(t, "QueuePopFront", got, err, "a", "b")
+	got, err = c.QueuePopBack("q", 2)
+	checkItems(t, "QueuePopBack", got, err, "e", "d")
+	got, err = c.QueuePopBack("q", 10)
+	checkItems(t, "QueuePopBack past the size", got, err, "c")
+	got, err = c.QueuePopFront("q", 1)
+	checkItems(t, "QueuePopFront of an empty queue", got, err)
+	if _, err := c.QueueFront("q"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("QueueFront of an empty queue: %v, want ErrNotFound", err)
+	}
+	if _, err := c.QueueBack("q"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("QueueBack of an empty queue: %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueueRangeSlice(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if _, err := c.QueuePushBack("q", "a", "b", "c", "d", "e"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		offset, limit int
+		want          []string
+	}{
+		{0, 2, []string{"a", "b"}},
+		{3, 10, []string{"d", "e"}},
+		{-2, 1, []string{"d"}},
+		{-10, 1, []string{"a"}},
+		{5, 1, nil},
+		{1, 0, nil},
+	} {
+		got, err := c.QueueRange("q", tt.offset, tt.limit)
+		checkItems(t, "QueueRange", got, err, tt.want...)
+	}
+	for _, tt := range []struct {
+		begin, end int
+		want       []string
+	}{
+		{0, 1, []string{"a", "b"}},
+		{1, 1, []string{"b"}},
+		{0, -1, []string{"a", "b", "c", "d", "e"}},
+		{-2, -1, []string{"d", "e"}},
+		{3, 100, []string{"d", "e"}},
+		{-100, 0, []string{"a"}},
+		{3, 1, nil},
+		{5, 6, nil},
+	} {
+		got, err := c.QueueSlice("q", tt.begin, tt.end)
+		checkItems(t, "QueueSlice", got, err, tt.want...)
+	}
+	if v, err := c.QueueGet("q", -1); err != nil || v != "e" {
+		t.Fatalf("QueueGet(-1) = %q, %v", v, err)
+	}
+	if _, err := c.QueueGet("q", 5); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("QueueGet past the end: %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueueTrim(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if _, err := c.QueuePushBack("q", "a", "b", "c", "d", "e"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.QueueTrimFront("q", 2); err != nil || n != 2 {
+		t.Fatalf("QueueTrimFront = %d, %v, want 2", n, err)
+	}
+	if n, err := c.QueueTrimBack("q", 1); err != nil || n != 1 {
+		t.Fatalf("QueueTrimBack = %d, %v, want 1", n, err)
+	}
+	got, err := c.QueueRange("q", 0, -1)
+	checkItems(t, "QueueRange after the trims", got, err, "c", "d")
+	if n, err := c.QueueTrimBack("q", 10); err != nil || n != 2 {
+		t.Fatalf("QueueTrimBack past the size = %d, %v, want 2", n, err)
+	}
+	if n, err := c.QueueTrimFront("q", 1); err != nil || n != 0 {
+		t.Fatalf("QueueTrimFront of an empty queue = %d, %v, want 0", n, err)
+	}
+}
+
+func TestQueueSizeEmpty(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if n, err := c.QueueSize("missing"); err != nil || n != 0 {
+		t.Fatalf("QueueSize of a missing queue = %d, %v, want 0", n, err)
+	}
+	if _, err := c.QueuePushBack("q", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.QueueSize("q"); err != nil || n != 1 {
+		t.Fatalf("QueueSize = %d, %v, want 1", n, err)
+	}
+	if _, err := c.QueuePopFront("q", 1); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.QueueSize("q"); err != nil || n != 0 {
+		t.Fatalf("QueueSize of an emptied queue = %d, %v, want 0", n, err)
+	}
+	if n, err := c.QueueClear("q"); err != nil || n != 0 {
+		t.Fatalf("QueueClear of an empty queue = %d, %v, want 0", n, err)
+	}
+}
@@ -0,0 +1,140 @@
+package ssdb
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy controls how a client reconnects after its connection
+// broke. The wait before attempt n is InitialBackoff*Multiplier^(n-1),
+// capped at MaxBackoff and randomized by Jitter so that many clients do not
+// reconnect at the same time.
+type ReconnectPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of the backoff, between 0 and 1, added to or
+	// removed from it at random.
+	Jitter float64
+	// MaxAttempts is the number of attempts before giving up, 0 means no
+	// limit.
+	MaxAttempts int
+	// OnGiveUp is called with the error of the last attempt when the client
+	// gives up. The client stays disconnected until RetryConnect is called.
+	OnGiveUp func(err error)
+	// OnStateChange is called on every reconnect event.
+	OnStateChange func(ev ReconnectEvent)
+}
+
+// DefaultReconnectPolicy is the policy of a client without one.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// ReconnectState is the state of a reconnecting client.
+type ReconnectState int
+
+const (
+	// StateReconnecting is sent when the connection is lost and after every
+	// failed attempt.
+	StateReconnecting ReconnectState = iota
+	// StateConnected is sent when an attempt succeeded.
+	StateConnected
+	// StateGaveUp is sent when MaxAttempts attempts failed.
+	StateGaveUp
+)
+
+func (s ReconnectState) String() string {
+	switch s {
+	case StateReconnecting:
+		return "reconnecting"
+	case StateConnected:
+		return "connected"
+	case StateGaveUp:
+		return "gave up"
+	}
+	return "unknown"
+}
+
+// ReconnectEvent describes a change of the reconnect state of a client.
+type ReconnectEvent struct {
+	State ReconnectState
+	// Attempt is the number of attempts made so far.
+	Attempt int
+	// Err is the error of the last attempt.
+	Err error
+	// Backoff is the wait before the next attempt.
+	Backoff time.Duration
+}
+
+// withDefaults fills the zero fields of p from DefaultReconnectPolicy.
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultReconnectPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultReconnectPolicy.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultReconnectPolicy.Multiplier
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	} else if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// backoff returns the wait before attempt.
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	d += d * p.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(d)
+}
+
+func (p ReconnectPolicy) notify(ev ReconnectEvent) {
+	if p.OnStateChange != nil {
+		p.OnStateChange(ev)
+	}
+}
+
+// run calls connect until it succeeds, the policy gives up or done is
+// closed. It waits a backoff before every attempt, the first one included,
+// so that the clients of a restarted server do not reconnect at once. It
+// returns the error of the last attempt when it did not connect.
+func (p ReconnectPolicy) run(connect func() error, done <-chan struct{}) error {
+	p = p.withDefaults()
+	wait := p.backoff(1)
+	p.notify(ReconnectEvent{State: StateReconnecting, Backoff: wait})
+	for attempt := 1; ; attempt++ {
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-done:
+			t.Stop()
+			return ErrClosed
+		}
+		err := connect()
+		if err == nil {
+			p.notify(ReconnectEvent{State: StateConnected, Attempt: attempt})
+			return nil
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			p.notify(ReconnectEvent{State: StateGaveUp, Attempt: attempt, Err: err})
+			if p.OnGiveUp != nil {
+				p.OnGiveUp(err)
+			}
+			return err
+		}
+		wait = p.backoff(attempt + 1)
+		p.notify(ReconnectEvent{State: StateReconnecting, Attempt: attempt, Err: err, Backoff: wait})
+	}
+}
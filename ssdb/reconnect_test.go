@@ -0,0 +1,263 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// eventLog records the reconnect events of a client.
+type eventLog struct {
+	mu     sync.Mutex
+	events []ssdb.ReconnectEvent
+}
+
+func (l *eventLog) add(ev ssdb.ReconnectEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, ev)
+}
+
+func (l *eventLog) states() []ssdb.ReconnectState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	states := make([]ssdb.ReconnectState, len(l.events))
+	for i, ev := range l.events {
+		states[i] = ev.State
+	}
+	return states
+}
+
+// waitFor calls f until it returns nil or a second passed.
+func waitFor(t *testing.T, f func() error) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := f()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	srv := newServer(t)
+	var events eventLog
+	c := dial(t, srv, ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		OnStateChange:  events.add,
+	}))
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	srv.CloseConnections()
+	_, err := c.Get("a")
+	if !ssdb.IsConnError(err) || !ssdb.IsRetryable(err) {
+		t.Fatalf("Get on a dropped connection: %v, want a retryable connection error", err)
+	}
+	waitFor(t, func() error {
+		_, err := c.Get("a")
+		return err
+	})
+	states := events.states()
+	if len(states) < 2 || states[0] != ssdb.StateReconnecting || states[len(states)-1] != ssdb.StateConnected {
+		t.Fatalf("events = %v", states)
+	}
+}
+
+func TestReconnectGiveUp(t *testing.T) {
+	srv, err := ssdbtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gaveUp := make(chan error, 1)
+	c, err := ssdb.Dial(srv.Addr, ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{
+		InitialBackoff: 5 * time.Millisecond,
+		MaxAttempts:    2,
+		OnGiveUp:       func(err error) { gaveUp <- err },
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	srv.Close()
+	c.Get("a")
+	select {
+	case err := <-gaveUp:
+		if err == nil {
+			t.Fatal("OnGiveUp called without an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the client did not give up")
+	}
+}
+
+func TestDoTimeout(t *testing.T) {
+	srv := newServer(t)
+	srv.AddFault("get", ssdbtest.Fault{Latency: 200 * time.Millisecond, Times: 1})
+	c := dial(t, srv)
+	start := time.Now()
+	_, err := c.Do(50, "get", "a")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do past its timeout: %v, want a deadline error", err)
+	}
+	if d := time.Since(start); d > 150*time.Millisecond {
+		t.Fatalf("Do returned after %v", d)
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	srv := newServer(t)
+	srv.AddFault("get", ssdbtest.Fault{Latency: 200 * time.Millisecond, Times: 1})
+	c := dial(t, srv, ssdb.WithReadTimeout(50*time.Millisecond),
+		ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}))
+	_, err := c.Get("a")
+	if !ssdb.IsConnError(err) || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get past the read timeout: %v, want a connection error", err)
+	}
+	waitFor(t, c.Ping)
+}
+
+func TestContextDeadline(t *testing.T) {
+	srv := newServer(t)
+	srv.AddFault("get", ssdbtest.Fault{Latency: 200 * time.Millisecond, Times: 1})
+	var events eventLog
+	c := dial(t, srv, ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{OnStateChange: events.add}))
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := c.GetContext(ctx, "a")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	// The set is queued behind the slow get, the expired get must not
+	// break the connection under it.
+	if err := c.Set("b", "1"); err != nil {
+		t.Fatalf("Set behind an expired get: %v", err)
+	}
+	err := <-done
+	if !errors.Is(err, context.DeadlineExceeded) || ssdb.IsConnError(err) || ssdb.IsRetryable(err) {
+		t.Fatalf("GetContext = %v, want a deadline error", err)
+	}
+	if v, err := c.Get("b"); err != nil || v != "1" {
+		t.Fatalf("Get = %q, %v, want 1", v, err)
+	}
+	if states := events.states(); len(states) != 0 {
+		t.Fatalf("the connection was broken, events = %v", states)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	srv := newServer(t)
+	var events eventLog
+	c := dial(t, srv, ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{OnStateChange: events.add}))
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	for _, ctx := range []context.Context{cancelled, expired} {
+		if err := c.SetContext(ctx, "a", "1"); !errors.Is(err, ctx.Err()) || ssdb.IsConnError(err) {
+			t.Fatalf("SetContext with a done context = %v, want %v", err, ctx.Err())
+		}
+		if err := c.Set("a", "2"); err != nil {
+			t.Fatalf("Set after a call with a done context: %v", err)
+		}
+	}
+	if v, err := c.Get("a"); err != nil || v != "2" {
+		t.Fatalf("Get = %q, %v, want 2", v, err)
+	}
+	if states := events.states(); len(states) != 0 {
+		t.Fatalf("the connection was broken, events = %v", states)
+	}
+}
+
+func TestChunkedResponse(t *testing.T) {
+	srv := newServer(t)
+	srv.AddFault("get", ssdbtest.Fault{WriteChunk: 3, ChunkDelay: time.Millisecond})
+	c := dial(t, srv)
+	if err := c.Set("a", "a value split in chunks"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get("a"); err != nil || v != "a value split in chunks" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv, ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}))
+	tests := []struct {
+		name      string
+		fault     ssdbtest.Fault
+		status    string
+		retryable bool
+		connError bool
+	}{
+		{"server error", ssdbtest.Fault{Response: []string{"error", "server busy"}}, ssdb.StatusError, true, false},
+		{"fail", ssdbtest.Fault{Response: []string{"fail", "bad value"}}, ssdb.StatusFail, false, false},
+		{"client error", ssdbtest.Fault{Response: []string{"client_error", "bad request"}}, ssdb.StatusClientError, false, false},
+		{"corrupt", ssdbtest.Fault{Corrupt: true}, "", true, true},
+		{"disconnect", ssdbtest.Fault{Disconnect: true, DisconnectAfter: 3}, "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waitFor(t, func() error {
+				_, err := c.Get("missing")
+				if errors.Is(err, ssdb.ErrNotFound) {
+					return nil
+				}
+				return err
+			})
+			srv.AddFault("get", tt.fault)
+			defer srv.ClearFaults()
+			_, err := c.Get("a")
+			var e *ssdb.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("Get: %v, want an *ssdb.Error", err)
+			}
+			if e.Status != tt.status || e.Retryable != tt.retryable || ssdb.IsConnError(err) != tt.connError {
+				t.Fatalf("Get: %v, status %q retryable %v conn error %v", err, e.Status, e.Retryable, ssdb.IsConnError(err))
+			}
+		})
+	}
+}
+
+func TestErrorMessageKeepsConnection(t *testing.T) {
+	srv := newServer(t)
+	var events eventLog
+	c := dial(t, srv, ssdb.WithReconnectPolicy(ssdb.ReconnectPolicy{OnStateChange: events.add}))
+	srv.AddFault("get", ssdbtest.Fault{Response: []string{"error", "connection limit reached"}, Times: 1})
+	_, err := c.ProcessCmd("get", []interface{}{"a"})
+	var e *ssdb.Error
+	if !errors.As(err, &e) || e.Status != ssdb.StatusError || ssdb.IsConnError(err) {
+		t.Fatalf("ProcessCmd = %v, want the server error", err)
+	}
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatalf("Set after a server error: %v", err)
+	}
+	if states := events.states(); len(states) != 0 {
+		t.Fatalf("a server error broke the connection, events = %v", states)
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	for _, err := range []error{context.Canceled, context.DeadlineExceeded, ssdb.ErrNotFound} {
+		if ssdb.IsConnError(err) {
+			t.Errorf("IsConnError(%v) = true", err)
+		}
+	}
+	if !ssdb.IsConnError(ssdb.ErrClosed) {
+		t.Error("IsConnError(ErrClosed) = false")
+	}
+}
@@ -0,0 +1,199 @@
+package ssdb
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+)
+
+// readCommands holds the commands that a ReplicatedClient sends to a slave,
+// the other commands are sent to the master.
+var readCommands = map[string]bool{
+	"ping": true, "info": true, "dbsize": true,
+
+	"get": true, "exists": true, "ttl": true, "multi_get": true, "scan": true,
+	"rscan": true, "keys": true, "rkeys": true,
+
+	"hget": true, "hexists": true, "hsize": true, "hkeys": true, "hrkeys": true,
+	"hgetall": true, "hscan": true, "hrscan": true, "multi_hget": true,
+	"hlist": true, "hrlist": true,
+
+	"zget": true, "zexists": true, "zsize": true, "zrank": true, "zrrank": true,
+	"zrange": true, "zrrange": true, "zscan": true, "zrscan": true, "zkeys": true,
+	"zcount": true, "zsum": true, "zavg": true, "multi_zget": true, "zlist": true,
+	"zrlist": true,
+
+	"qfront": true, "qback": true, "qsize": true, "qget": true, "qrange": true,
+	"qslice": true, "qlist": true, "qrlist": true,
+}
+
+type masterKey struct{}
+
+// ReadFromMaster returns a context whose read commands are sent to the
+// master by a ReplicatedClient, to read a value just written:
+//
+//	db.Set("a", "1")
+//	db.GetContext(ssdb.ReadFromMaster(ctx), "a")
+func ReadFromMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, masterKey{}, true)
+}
+
+func readFromMaster(ctx context.Context) bool {
+	on, _ := ctx.Value(masterKey{}).(bool)
+	return on
+}
+
+// Balancer picks the slave that runs a read command of a ReplicatedClient
+// among the healthy slaves, there is at least one. It is called
+// concurrently.
+type Balancer interface {
+	Pick(slaves []*Client) *Client
+}
+
+// BalancerFunc is a function used as a Balancer.
+type BalancerFunc func(slaves []*Client) *Client
+
+func (f BalancerFunc) Pick(slaves []*Client) *Client {
+	return f(slaves)
+}
+
+// RoundRobinBalancer returns a Balancer that picks the slaves in turn.
+func RoundRobinBalancer() Balancer {
+	var next atomic.Uint64
+	return BalancerFunc(func(slaves []*Client) *Client {
+		return slaves[(next.Add(1)-1)%uint64(len(slaves))]
+	})
+}
+
+// RandomBalancer returns a Balancer that picks a random slave.
+func RandomBalancer() Balancer {
+	return BalancerFunc(func(slaves []*Client) *Client {
+		return slaves[rand.Intn(len(slaves))]
+	})
+}
+
+// ReplicatedConfig controls a ReplicatedClient.
+type ReplicatedConfig struct {
+	// Balancer picks the slave of a read command, RoundRobinBalancer when
+	// nil.
+	Balancer Balancer
+	// Logger receives the log messages of the replicated client.
+	Logger Logger
+	// Hooks are called around the commands of the replicated client, the
+	// hooks of the master and the slaves are called for the commands they
+	// run.
+	Hooks []Hook
+}
+
+// ReplicatedClient splits the commands between a master and its slaves.
+// Writes, and the commands it does not know, e.g. batchexec, go to the
+// master. Reads go to a healthy slave chosen by the balancer, to the master
+// when no slave is connected or when the slave failed on its connection.
+// Slaves lag behind the master, use ReadFromMaster to read your writes.
+//
+// The embedded Client has the helpers, Do, ProcessCmd, Pipeline and the
+// hooks of a Client, and can be given to Namespaced. A pipeline holding only
+// reads goes to a slave, any other pipeline to the master.
+type ReplicatedClient struct {
+	*Client
+	master   *Client
+	slaves   []*Client
+	balancer Balancer
+}
+
+// NewReplicatedClient returns a client over master and slaves, they are
+// closed with it.
+func NewReplicatedClient(master *Client, slaves []*Client, config ReplicatedConfig) *ReplicatedClient {
+	if config.Balancer == nil {
+		config.Balancer = RoundRobinBalancer()
+	}
+	r := &ReplicatedClient{
+		master:   master,
+		slaves:   append([]*Client(nil), slaves...),
+		balancer: config.Balancer,
+	}
+	o := defaultOptions()
+	o.logger = config.Logger
+	o.hooks = config.Hooks
+	r.Client = newRoutedClient(r, o)
+	return r
+}
+
+// Master returns the client of the master.
+func (r *ReplicatedClient) Master() *Client {
+	return r.master
+}
+
+// Slaves returns the clients of the slaves.
+func (r *ReplicatedClient) Slaves() []*Client {
+	return append([]*Client(nil), r.slaves...)
+}
+
+// Close closes the client, the master and the slaves.
+func (r *ReplicatedClient) Close() error {
+	r.Client.Close()
+	err := r.master.Close()
+	for _, c := range r.slaves {
+		c.Close()
+	}
+	return err
+}
+
+// slave returns a healthy slave picked by the balancer, nil when there is
+// none.
+func (r *ReplicatedClient) slave() *Client {
+	healthy := make([]*Client, 0, len(r.slaves))
+	for _, c := range r.slaves {
+		if c.alive() {
+			healthy = append(healthy, c)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	return r.balancer.Pick(healthy)
+}
+
+// reader returns the client that runs reads in ctx, nil for the master.
+func (r *ReplicatedClient) reader(ctx context.Context) *Client {
+	if readFromMaster(ctx) {
+		return nil
+	}
+	return r.slave()
+}
+
+func (r *ReplicatedClient) do(ctx context.Context, args []interface{}) ([]string, error) {
+	if !readCommands[cmdName(args)] {
+		return r.master.DoContext(ctx, args...)
+	}
+	c := r.reader(ctx)
+	if c == nil {
+		return r.master.DoContext(ctx, args...)
+	}
+	resp, err := c.DoContext(ctx, args...)
+	if err != nil && IsConnError(err) && ctx.Err() == nil {
+		r.logger().Warn("read failed on slave, reading from master", "client", c.Id, "cmd", cmdName(args), "err", err)
+		return r.master.DoContext(ctx, args...)
+	}
+	return resp, err
+}
+
+func (r *ReplicatedClient) exec(ctx context.Context, cmds []*Cmd) error {
+	c := r.master
+	if reads(cmds) {
+		if s := r.reader(ctx); s != nil {
+			c = s
+		}
+	}
+	return execOn(ctx, c, cmds)
+}
+
+// reads reports whether all cmds are reads.
+func reads(cmds []*Cmd) bool {
+	for _, cmd := range cmds {
+		if !readCommands[cmd.Name] {
+			return false
+		}
+	}
+	return true
+}
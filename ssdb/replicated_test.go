@@ -0,0 +1,153 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// newReplicated returns a replicated client over a master and two slaves
+// whose key k holds m, s1 and s2, and their servers.
+func newReplicated(t *testing.T, config ssdb.ReplicatedConfig) (*ssdb.ReplicatedClient, []*ssdbtest.Server) {
+	t.Helper()
+	var servers []*ssdbtest.Server
+	var clients []*ssdb.Client
+	for _, val := range []string{"m", "s1", "s2"} {
+		srv := newServer(t)
+		c := dial(t, srv)
+		if err := c.Set("k", val); err != nil {
+			t.Fatal(err)
+		}
+		servers = append(servers, srv)
+		clients = append(clients, c)
+	}
+	r := ssdb.NewReplicatedClient(clients[0], clients[1:], config)
+	t.Cleanup(func() { r.Close() })
+	return r, servers
+}
+
+// readK reads the key k with ctx.
+func readK(t *testing.T, r *ssdb.ReplicatedClient, ctx context.Context) string {
+	t.Helper()
+	v, err := r.GetContext(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestReplicatedSplit(t *testing.T) {
+	r, servers := newReplicated(t, ssdb.ReplicatedConfig{})
+	ctx := context.Background()
+	if err := r.Set("w", "1"); err != nil {
+		t.Fatal(err)
+	}
+	for i, srv := range servers {
+		_, err := dial(t, srv).Get("w")
+		if (i == 0) != (err == nil) {
+			t.Fatalf("write on server %d: %v, want it on the master only", i, err)
+		}
+	}
+	// The round robin balancer takes the slaves in turn.
+	for _, want := range []string{"s1", "s2", "s1"} {
+		if got := readK(t, r, ctx); got != want {
+			t.Fatalf("Get = %q, want %q", got, want)
+		}
+	}
+	if got := readK(t, r, ssdb.ReadFromMaster(ctx)); got != "m" {
+		t.Fatalf("Get with ReadFromMaster = %q, want m", got)
+	}
+	if _, err := r.Incr("n", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Master().Get("n"); err != nil {
+		t.Fatalf("incr did not reach the master: %v", err)
+	}
+}
+
+func TestReplicatedBalancer(t *testing.T) {
+	var picks [][]*ssdb.Client
+	r, _ := newReplicated(t, ssdb.ReplicatedConfig{
+		Balancer: ssdb.BalancerFunc(func(slaves []*ssdb.Client) *ssdb.Client {
+			picks = append(picks, slaves)
+			return slaves[len(slaves)-1]
+		}),
+	})
+	for i := 0; i < 2; i++ {
+		if got := readK(t, r, context.Background()); got != "s2" {
+			t.Fatalf("Get = %q, want the slave picked s2", got)
+		}
+	}
+	if len(picks) != 2 || len(picks[0]) != 2 {
+		t.Fatalf("the balancer picked among %v", picks)
+	}
+	if err := r.Set("w", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(picks) != 2 {
+		t.Fatal("the balancer was asked for a write")
+	}
+}
+
+func TestReplicatedUnhealthySlaves(t *testing.T) {
+	r, _ := newReplicated(t, ssdb.ReplicatedConfig{})
+	ctx := context.Background()
+	slaves := r.Slaves()
+	slaves[0].Close()
+	for i := 0; i < 3; i++ {
+		if got := readK(t, r, ctx); got != "s2" {
+			t.Fatalf("Get = %q, want the healthy slave s2", got)
+		}
+	}
+	slaves[1].Close()
+	if got := readK(t, r, ctx); got != "m" {
+		t.Fatalf("Get without healthy slaves = %q, want the master", got)
+	}
+}
+
+func TestReplicatedSlaveFailure(t *testing.T) {
+	r, servers := newReplicated(t, ssdb.ReplicatedConfig{
+		Balancer: ssdb.BalancerFunc(func(slaves []*ssdb.Client) *ssdb.Client { return slaves[0] }),
+	})
+	// A connection failure on the slave is retried on the master, a server
+	// error is returned as it is.
+	servers[1].AddFault("get", ssdbtest.Fault{Disconnect: true, Times: 1})
+	if got := readK(t, r, context.Background()); got != "m" {
+		t.Fatalf("Get after a slave disconnect = %q, want the master", got)
+	}
+	servers[1].ClearFaults()
+	waitFor(t, r.Slaves()[0].Ping)
+	servers[1].AddFault("get", ssdbtest.Fault{Response: []string{"error", "server busy"}, Times: 1})
+	var e *ssdb.Error
+	if _, err := r.Get("k"); !errors.As(err, &e) || e.Status != ssdb.StatusError {
+		t.Fatalf("Get = %v, want the error of the slave", err)
+	}
+}
+
+func TestReplicatedPipeline(t *testing.T) {
+	r, _ := newReplicated(t, ssdb.ReplicatedConfig{
+		Balancer: ssdb.BalancerFunc(func(slaves []*ssdb.Client) *ssdb.Client { return slaves[0] }),
+	})
+	p := r.Pipeline()
+	p.Append("get", "k")
+	p.Append("exists", "k")
+	resps, err := p.Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resps[0][1] != "s1" {
+		t.Fatalf("read pipeline got %q, want it on the slave", resps[0])
+	}
+	p = r.Pipeline()
+	p.Append("get", "k")
+	p.Append("set", "w", "1")
+	if resps, err = p.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if resps[0][1] != "m" {
+		t.Fatalf("mixed pipeline got %q, want it on the master", resps[0])
+	}
+}
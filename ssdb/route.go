@@ -0,0 +1,46 @@
+package ssdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// router runs the commands of a client that has no connection of its own,
+// e.g. the client of a ShardedClient. The commands reach the router after
+// the namespace and the hooks of the client were applied.
+type router interface {
+	do(ctx context.Context, args []interface{}) ([]string, error)
+	exec(ctx context.Context, cmds []*Cmd) error
+}
+
+// newRoutedClient returns a client whose commands are run by r, so that it
+// has the helpers, the pipelines and the hooks of a Client. It is connected
+// until closed.
+func newRoutedClient(r router, o options) *Client {
+	return &Client{
+		Id:        fmt.Sprintf("Cl-%d", time.Now().UnixNano()),
+		Connected: true,
+		mu:        &sync.Mutex{},
+		done:      make(chan struct{}),
+		opts:      o,
+		hooks:     o.hooks,
+		route:     r,
+	}
+}
+
+// execOn runs cmds as a pipeline of c, between the pipeline hooks of c. The
+// hooks of c get copies of cmds, so that they do not see the changes made
+// by the hooks of the routed client.
+func execOn(ctx context.Context, c *Client, cmds []*Cmd) error {
+	sub := make([]*Cmd, len(cmds))
+	for i, cmd := range cmds {
+		sub[i] = &Cmd{Name: cmd.Name, Args: cmd.Args}
+	}
+	err := c.getHooks().processPipeline(ctx, sub, c.exec)
+	for i, cmd := range cmds {
+		cmd.done(sub[i].Resp, sub[i].Err, sub[i].Duration)
+	}
+	return err
+}
@@ -0,0 +1,433 @@
+package ssdb
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultVirtualNodes is the number of points a shard of weight 1 has on the
+// ring of a ShardedClient.
+const DefaultVirtualNodes = 160
+
+// Shard is a server of a ShardedClient.
+type Shard struct {
+	// Name places the shard on the ring, the address of Client when empty.
+	// Renaming a shard moves its keys to other shards.
+	Name   string
+	Client *Client
+	// Weight is the share of the keys of the shard relative to the other
+	// shards, 1 when 0.
+	Weight int
+}
+
+func (s Shard) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Client.Port == 0 {
+		return s.Client.Ip
+	}
+	return fmt.Sprintf("%s:%d", s.Client.Ip, s.Client.Port)
+}
+
+// ShardedConfig controls a ShardedClient.
+type ShardedConfig struct {
+	// VirtualNodes is the number of points a shard of weight 1 has on the
+	// ring, DefaultVirtualNodes when 0. More points spread the keys more
+	// evenly.
+	VirtualNodes int
+	// Logger receives the log messages of the sharded client.
+	Logger Logger
+	// Hooks are called around the commands of the sharded client, before
+	// they are split between the shards. The hooks of the shards are called
+	// for the commands sent to each shard.
+	Hooks []Hook
+}
+
+// ShardedClient spreads the keys over several servers with a consistent
+// hash ring. Each command is sent to the shard owning its key, the hash name
+// for the hash commands and the set or queue name for the sorted set and
+// queue commands. multi_get, multi_set and multi_del are split between the
+// shards, and the listing commands, e.g. Scan, HashList and ZList, are sent
+// to all the shards and their results merged in key order up to the limit.
+// ping is sent to all the shards and dbsize adds up their sizes.
+//
+// The embedded Client has the helpers, Do, ProcessCmd, Pipeline and the
+// hooks of a Client, and can be given to Namespaced. Commands that do not
+// name their keys, e.g. info, auth and batchexec, are refused. A pipeline
+// sends the commands of each shard in one write, the multi-key and listing
+// commands are run after the others.
+//
+// Shards can be added and removed while the client is in use, the keys
+// moving to another shard are not copied.
+type ShardedClient struct {
+	*Client
+	vnodes int
+	ringMu sync.RWMutex
+	shards map[string]Shard
+	ring   hashRing
+}
+
+// NewShardedClient returns a client over shards, the shard clients are
+// closed with it.
+func NewShardedClient(shards []Shard, config ShardedConfig) (*ShardedClient, error) {
+	if config.VirtualNodes <= 0 {
+		config.VirtualNodes = DefaultVirtualNodes
+	}
+	s := &ShardedClient{
+		vnodes: config.VirtualNodes,
+		shards: make(map[string]Shard),
+	}
+	for _, shard := range shards {
+		if err := s.add(shard); err != nil {
+			return nil, err
+		}
+	}
+	o := defaultOptions()
+	o.logger = config.Logger
+	o.hooks = config.Hooks
+	s.Client = newRoutedClient(s, o)
+	s.ring = newHashRing(s.shards, s.vnodes)
+	return s, nil
+}
+
+func (s *ShardedClient) add(shard Shard) error {
+	if shard.Client == nil {
+		return fmt.Errorf("ssdb: shard %q has no client", shard.Name)
+	}
+	if shard.Weight <= 0 {
+		shard.Weight = 1
+	}
+	shard.Name = shard.name()
+	if _, ok := s.shards[shard.Name]; ok {
+		return fmt.Errorf("ssdb: duplicate shard %q", shard.Name)
+	}
+	s.shards[shard.Name] = shard
+	return nil
+}
+
+// AddShard adds a shard to the ring, it takes over its share of the keys of
+// the other shards.
+func (s *ShardedClient) AddShard(shard Shard) error {
+	s.ringMu.Lock()
+	defer s.ringMu.Unlock()
+	if err := s.add(shard); err != nil {
+		return err
+	}
+	s.ring = newHashRing(s.shards, s.vnodes)
+	s.logger().Info("shard added", "shard", shard.name(), "shards", len(s.shards))
+	return nil
+}
+
+// RemoveShard removes the shard called name from the ring, its keys go to
+// the other shards. The client of the shard is returned and not closed, nil
+// when there is no such shard.
+func (s *ShardedClient) RemoveShard(name string) *Client {
+	s.ringMu.Lock()
+	defer s.ringMu.Unlock()
+	shard, ok := s.shards[name]
+	if !ok {
+		return nil
+	}
+	delete(s.shards, name)
+	s.ring = newHashRing(s.shards, s.vnodes)
+	s.logger().Info("shard removed", "shard", name, "shards", len(s.shards))
+	return shard.Client
+}
+
+// Shards returns the shards sorted by name.
+func (s *ShardedClient) Shards() []Shard {
+	s.ringMu.RLock()
+	defer s.ringMu.RUnlock()
+	shards := make([]Shard, 0, len(s.shards))
+	for _, shard := range s.shards {
+		shards = append(shards, shard)
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i].Name < shards[j].Name })
+	return shards
+}
+
+// ShardFor returns the client of the shard owning key, nil when there is no
+// shard.
+func (s *ShardedClient) ShardFor(key string) *Client {
+	s.ringMu.RLock()
+	defer s.ringMu.RUnlock()
+	return s.shardFor(key)
+}
+
+func (s *ShardedClient) shardFor(key string) *Client {
+	name, ok := s.ring.get(key)
+	if !ok {
+		return nil
+	}
+	return s.shards[name].Client
+}
+
+// clients returns the clients of all the shards.
+func (s *ShardedClient) clients() []*Client {
+	s.ringMu.RLock()
+	defer s.ringMu.RUnlock()
+	clients := make([]*Client, 0, len(s.shards))
+	for _, shard := range s.shards {
+		clients = append(clients, shard.Client)
+	}
+	return clients
+}
+
+// Close closes the client and the clients of the shards.
+func (s *ShardedClient) Close() error {
+	s.Client.Close()
+	var firstErr error
+	for _, c := range s.clients() {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// do runs a command on the shards owning its keys.
+func (s *ShardedClient) do(ctx context.Context, args []interface{}) ([]string, error) {
+	name := cmdName(args)
+	rule, ok := commandKeys[name]
+	if !ok || (rule.args == keyNone && name != "ping" && name != "dbsize") {
+		return nil, fmt.Errorf("ssdb: command %q is not supported by a sharded client", name)
+	}
+	switch rule.args {
+	case keyFirst:
+		var key string
+		if len(args) > 1 {
+			key = argString(args[1])
+		}
+		s.ringMu.RLock()
+		c := s.shardFor(key)
+		s.ringMu.RUnlock()
+		if c == nil {
+			return nil, connError(name, ErrNoShards)
+		}
+		return c.DoContext(ctx, args...)
+	case keyAll, keyPairs:
+		step := 1
+		if rule.args == keyPairs {
+			step = 2
+		}
+		groups := make(map[*Client][]interface{})
+		var order []*Client
+		s.ringMu.RLock()
+		for i := 1; i+step <= len(args); i += step {
+			c := s.shardFor(argString(args[i]))
+			if c == nil {
+				s.ringMu.RUnlock()
+				return nil, connError(name, ErrNoShards)
+			}
+			if _, ok := groups[c]; !ok {
+				groups[c] = []interface{}{name}
+				order = append(order, c)
+			}
+			groups[c] = append(groups[c], args[i:i+step]...)
+		}
+		s.ringMu.RUnlock()
+		calls := make([]shardCall, len(order))
+		for i, c := range order {
+			calls[i] = shardCall{c: c, args: groups[c]}
+		}
+		return s.fanOut(ctx, rule, args, calls)
+	default:
+		clients := s.clients()
+		if len(clients) == 0 {
+			return nil, connError(name, ErrNoShards)
+		}
+		calls := make([]shardCall, len(clients))
+		for i, c := range clients {
+			calls[i] = shardCall{c: c, args: args}
+		}
+		return s.fanOut(ctx, rule, args, calls)
+	}
+}
+
+type shardCall struct {
+	c    *Client
+	args []interface{}
+}
+
+// fanOut runs calls concurrently and merges their responses into the
+// response of the command args.
+func (s *ShardedClient) fanOut(ctx context.Context, rule keyRule, args []interface{}, calls []shardCall) ([]string, error) {
+	resps := make([][]string, len(calls))
+	errs := make([]error, len(calls))
+	var wg sync.WaitGroup
+	wg.Add(len(calls))
+	for i, call := range calls {
+		go func(i int, call shardCall) {
+			defer wg.Done()
+			resps[i], errs[i] = call.c.DoContext(ctx, call.args...)
+		}(i, call)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, resp := range resps {
+		if len(resp) == 0 || resp[0] != StatusOK {
+			return resp, nil
+		}
+	}
+	if rule.list {
+		return mergeList(rule, args, resps), nil
+	}
+	if len(calls) > 0 && len(resps[0]) == 1 {
+		return []string{StatusOK}, nil
+	}
+	var sum int64
+	for _, resp := range resps {
+		if len(resp) > 1 {
+			n, _ := strconv.ParseInt(resp[1], 10, 64)
+			sum += n
+		}
+	}
+	return []string{StatusOK, strconv.FormatInt(sum, 10)}, nil
+}
+
+// mergeList merges the list responses of the shards in key order, up to the
+// limit of a range command.
+func mergeList(rule keyRule, args []interface{}, resps [][]string) []string {
+	step := 1
+	if rule.pairs {
+		step = 2
+	}
+	var entries [][]string
+	for _, resp := range resps {
+		for i := 1; i+step <= len(resp); i += step {
+			entries = append(entries, resp[i:i+step])
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if rule.reverse {
+			return entries[i][0] > entries[j][0]
+		}
+		return entries[i][0] < entries[j][0]
+	})
+	if rule.args == keyRange && len(args) > 3 {
+		limit, err := strconv.Atoi(argString(args[3]))
+		if err == nil && limit >= 0 && limit < len(entries) {
+			entries = entries[:limit]
+		}
+	}
+	out := make([]string, 1, 1+len(entries)*step)
+	out[0] = StatusOK
+	for _, entry := range entries {
+		out = append(out, entry...)
+	}
+	return out
+}
+
+// exec sends the single key commands of cmds to their shards, one pipeline
+// per shard, then runs the other commands one by one.
+func (s *ShardedClient) exec(ctx context.Context, cmds []*Cmd) error {
+	start := time.Now()
+	routed := make([]bool, len(cmds))
+	groups := make(map[*Client][]*Cmd)
+	s.ringMu.RLock()
+	for i, cmd := range cmds {
+		rule, ok := commandKeys[cmd.Name]
+		if !ok || rule.args != keyFirst || len(cmd.Args) == 0 {
+			continue
+		}
+		if c := s.shardFor(argString(cmd.Args[0])); c != nil {
+			routed[i] = true
+			groups[c] = append(groups[c], cmd)
+		}
+	}
+	s.ringMu.RUnlock()
+	var wg sync.WaitGroup
+	for c, group := range groups {
+		wg.Add(1)
+		go func(c *Client, group []*Cmd) {
+			defer wg.Done()
+			execOn(ctx, c, group)
+		}(c, group)
+	}
+	wg.Wait()
+	var firstErr error
+	for i, cmd := range cmds {
+		if !routed[i] {
+			resp, err := s.do(ctx, cmd.args())
+			cmd.done(resp, err, 0)
+		}
+		if cmd.Err != nil && firstErr == nil {
+			firstErr = cmd.Err
+		}
+	}
+	d := time.Since(start)
+	for _, cmd := range cmds {
+		cmd.Duration = d
+	}
+	return firstErr
+}
+
+// hashRing maps keys to shard names, each shard has weight*vnodes points on
+// the ring and owns the keys hashed between its points and the previous
+// points.
+type hashRing struct {
+	points []uint32
+	names  []string
+}
+
+func newHashRing(shards map[string]Shard, vnodes int) hashRing {
+	type point struct {
+		hash uint32
+		name string
+	}
+	var points []point
+	for name, shard := range shards {
+		for i := 0; i < shard.Weight*vnodes; i++ {
+			points = append(points, point{ringHash(name + "#" + strconv.Itoa(i)), name})
+		}
+	}
+	// ties are broken by name so that the ring does not depend on the map
+	// order
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].name < points[j].name
+	})
+	r := hashRing{
+		points: make([]uint32, len(points)),
+		names:  make([]string, len(points)),
+	}
+	for i, p := range points {
+		r.points[i] = p.hash
+		r.names[i] = p.name
+	}
+	return r
+}
+
+// get returns the name of the shard owning key.
+func (r hashRing) get(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+	h := ringHash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.names[i], true
+}
+
+// ringHash places keys and points on the ring with the first bytes of their
+// md5 sum, as ketama does, short keys that differ by a byte are spread
+// evenly.
+func ringHash(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+	return binary.LittleEndian.Uint32(sum[:4])
+}
@@ -0,0 +1,317 @@
+package ssdb_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// newShard starts a server and returns a shard on it called name.
+func newShard(t *testing.T, name string, weight int) (ssdb.Shard, *ssdbtest.Server) {
+	t.Helper()
+	srv := newServer(t)
+	return ssdb.Shard{Name: name, Client: dial(t, srv), Weight: weight}, srv
+}
+
+// newSharded returns a sharded client over shards a, b and c, and their
+// servers by name.
+func newSharded(t *testing.T) (*ssdb.ShardedClient, map[string]*ssdbtest.Server) {
+	t.Helper()
+	var shards []ssdb.Shard
+	servers := make(map[string]*ssdbtest.Server)
+	for _, name := range []string{"a", "b", "c"} {
+		shard, srv := newShard(t, name, 0)
+		shards = append(shards, shard)
+		servers[name] = srv
+	}
+	s, err := ssdb.NewShardedClient(shards, ssdb.ShardedConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, servers
+}
+
+// owners returns the shard names owning keys.
+func owners(s *ssdb.ShardedClient, keys []string) map[string]string {
+	names := make(map[*ssdb.Client]string)
+	for _, shard := range s.Shards() {
+		names[shard.Client] = shard.Name
+	}
+	owner := make(map[string]string, len(keys))
+	for _, key := range keys {
+		owner[key] = names[s.ShardFor(key)]
+	}
+	return owner
+}
+
+func testKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%04d", i)
+	}
+	return keys
+}
+
+func TestShardedDistribution(t *testing.T) {
+	a, _ := newShard(t, "a", 1)
+	b, _ := newShard(t, "b", 1)
+	c, _ := newShard(t, "c", 2)
+	s, err := ssdb.NewShardedClient([]ssdb.Shard{a, b, c}, ssdb.ShardedConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	keys := testKeys(10000)
+	counts := make(map[string]int)
+	for _, name := range owners(s, keys) {
+		counts[name]++
+	}
+	want := map[string]float64{"a": 0.25, "b": 0.25, "c": 0.5}
+	for name, share := range want {
+		got := float64(counts[name]) / float64(len(keys))
+		if got < share-0.06 || got > share+0.06 {
+			t.Errorf("shard %s owns %.3f of the keys, want about %.2f", name, got, share)
+		}
+	}
+
+	// The ring depends on the names and weights only, not on the order of
+	// the shards.
+	s2, err := ssdb.NewShardedClient([]ssdb.Shard{c, a, b}, ssdb.ShardedConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(owners(s, keys), owners(s2, keys)) {
+		t.Error("the routing depends on the order of the shards")
+	}
+	s2.Client.Close()
+
+	// Fewer virtual nodes still route every key to a shard.
+	s3, err := ssdb.NewShardedClient([]ssdb.Shard{a, b, c}, ssdb.ShardedConfig{VirtualNodes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, name := range owners(s3, keys[:100]) {
+		if name == "" {
+			t.Fatalf("key %s has no shard", key)
+		}
+	}
+	s3.Client.Close()
+}
+
+func TestShardedRouting(t *testing.T) {
+	s, servers := newSharded(t)
+	keys := testKeys(100)
+	owner := owners(s, keys)
+	for _, key := range keys {
+		if err := s.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.HashSet(key, "f", key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw := make(map[string]*ssdb.Client)
+	for name, srv := range servers {
+		raw[name] = dial(t, srv)
+	}
+	for _, key := range keys {
+		for name, c := range raw {
+			_, err := c.Get(key)
+			if name == owner[key] && err != nil {
+				t.Fatalf("key %s missing on its shard %s: %v", key, name, err)
+			}
+			if name != owner[key] && !errors.Is(err, ssdb.ErrNotFound) {
+				t.Fatalf("key %s of shard %s found on %s", key, owner[key], name)
+			}
+			_, err = c.HashGet(key, "f")
+			if name == owner[key] && err != nil {
+				t.Fatalf("hash %s missing on its shard %s: %v", key, name, err)
+			}
+		}
+		if v, err := s.Get(key); err != nil || v != key {
+			t.Fatalf("Get(%s) = %q, %v", key, v, err)
+		}
+	}
+	if !reflect.DeepEqual(owners(s, keys), owner) {
+		t.Fatal("the routing changed between calls")
+	}
+}
+
+func TestShardedAddRemove(t *testing.T) {
+	s, _ := newSharded(t)
+	keys := testKeys(5000)
+	before := owners(s, keys)
+
+	d, _ := newShard(t, "d", 0)
+	if err := s.AddShard(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddShard(d); err == nil {
+		t.Fatal("AddShard accepted a duplicate shard")
+	}
+	moved := 0
+	for key, name := range owners(s, keys) {
+		if name != before[key] {
+			if name != "d" {
+				t.Fatalf("key %s moved from %s to %s, not to the new shard", key, before[key], name)
+			}
+			moved++
+		}
+	}
+	if share := float64(moved) / float64(len(keys)); share < 0.15 || share > 0.35 {
+		t.Errorf("%.3f of the keys moved to the new shard, want about 0.25", share)
+	}
+
+	if c := s.RemoveShard("d"); c != d.Client {
+		t.Fatalf("RemoveShard returned %v, want the client of d", c)
+	}
+	if c := s.RemoveShard("d"); c != nil {
+		t.Fatal("RemoveShard removed a missing shard")
+	}
+	if !reflect.DeepEqual(owners(s, keys), before) {
+		t.Fatal("the keys did not return to their shards")
+	}
+}
+
+func TestShardedConcurrentChanges(t *testing.T) {
+	s, _ := newSharded(t)
+	d, _ := newShard(t, "d", 0)
+	keys := testKeys(50)
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := keys[i%len(keys)]
+				if err := s.Set(key, "v"); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := s.Get(key); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := s.AddShard(d); err != nil {
+			t.Fatal(err)
+		}
+		s.RemoveShard("d")
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestShardedMerge(t *testing.T) {
+	s, _ := newSharded(t)
+	keys := testKeys(20)
+	for _, key := range keys {
+		if err := s.Set(key, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(uniqueOwners(owners(s, keys))) < 2 {
+		t.Fatal("the keys are on a single shard")
+	}
+
+	resp, err := s.Do("keys", "", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]string{ssdb.StatusOK}, keys[:5]...); !reflect.DeepEqual(resp, want) {
+		t.Errorf("keys = %q, want %q", resp, want)
+	}
+	resp, err = s.Do("rkeys", "", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{ssdb.StatusOK, keys[19], keys[18], keys[17]}; !reflect.DeepEqual(resp, want) {
+		t.Errorf("rkeys = %q, want %q", resp, want)
+	}
+	resp, err = s.Do("scan", keys[2], "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{ssdb.StatusOK, keys[3], "v", keys[4], "v"}; !reflect.DeepEqual(resp, want) {
+		t.Errorf("scan = %q, want %q", resp, want)
+	}
+	if n, err := s.Do("dbsize"); err != nil || len(n) != 2 || n[1] != "20" {
+		t.Errorf("dbsize = %q, %v, want 20", n, err)
+	}
+}
+
+func uniqueOwners(owner map[string]string) map[string]bool {
+	names := make(map[string]bool)
+	for _, name := range owner {
+		names[name] = true
+	}
+	return names
+}
+
+func TestShardedErrors(t *testing.T) {
+	s, servers := newSharded(t)
+	// One key per shard so that the multi-key commands reach all of them.
+	var keys []string
+	seen := make(map[string]bool)
+	for key, name := range owners(s, testKeys(100)) {
+		if !seen[name] {
+			seen[name] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 3 {
+		t.Fatalf("keys %v do not cover the shards", keys)
+	}
+	for _, key := range keys {
+		if err := s.Set(key, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		cmd   string
+		args  []interface{}
+		fault ssdbtest.Fault
+	}{
+		{"scan", []interface{}{"", "", 10}, ssdbtest.Fault{Response: []string{"error", "server busy"}}},
+		{"multi_get", []interface{}{keys[0], keys[1], keys[2]}, ssdbtest.Fault{Response: []string{"error", "server busy"}}},
+		{"multi_del", []interface{}{keys[0], keys[1], keys[2]}, ssdbtest.Fault{Disconnect: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			srv := servers["b"]
+			srv.AddFault(tt.cmd, tt.fault)
+			defer srv.ClearFaults()
+			_, err := s.ProcessCmd(tt.cmd, tt.args)
+			var e *ssdb.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("ProcessCmd(%s) = %v, want the error of shard b", tt.cmd, err)
+			}
+			if tt.fault.Disconnect != ssdb.IsConnError(err) {
+				t.Fatalf("ProcessCmd(%s) = %v, conn error %v", tt.cmd, err, ssdb.IsConnError(err))
+			}
+		})
+	}
+
+	empty, err := ssdb.NewShardedClient(nil, ssdb.ShardedConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer empty.Close()
+	if _, err := empty.Get("a"); !errors.Is(err, ssdb.ErrNoShards) {
+		t.Fatalf("Get without shards: %v, want ErrNoShards", err)
+	}
+}
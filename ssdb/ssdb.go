@@ -1,31 +1,26 @@
 package ssdb
 
 import (
-	"bytes"
-	"compress/gzip"
-	"encoding/base64"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	_ "io"
-	"io/ioutil"
-	"log"
 	"math"
 	"net"
-	"reflect"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	_ "syscall"
 	"time"
-	"unsafe"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
 )
 
 type Client struct {
-	sock      net.Conn
-	recv_buf  bytes.Buffer
-	process   chan []interface{}
+	conn      *conn
+	unread    []*request
 	batchBuf  [][]interface{}
-	result    chan ClientResult
 	Id        string
 	Ip        string
 	Port      int
@@ -34,8 +29,16 @@ type Client struct {
 	Retry     bool
 	mu        *sync.Mutex
 	Closed    bool
-	init      bool
 	zip       bool
+	pool      *Pool
+	created   time.Time
+	used      time.Time
+	reconnect ReconnectPolicy
+	done      chan struct{}
+	opts      options
+	debug     atomic.Bool
+	hooks     hooks
+	route     router
 }
 
 type ClientResult struct {
@@ -55,26 +58,30 @@ type HashData struct {
 	Value    string
 }
 
-var debug bool = false
 var version string = "0.1.8"
 
 const layout = "2006-01-06 15:04:05"
 
-func Connect(host string, port int, auth string) (*Client, error) {
+// Connect connects to the server at host and port with the password auth
+// and opts, e.g. WithLogger. It returns the client and keeps reconnecting
+// when the connect fails.
+func Connect(host string, port int, auth string, opts ...Option) (*Client, error) {
 	ip := net.ParseIP(host)
 	if ip == nil {
 		ips, err := net.LookupIP(host)
 		if err != nil || len(ips) == 0 {
-			log.Printf("Connect failed: The host or ip incorrect.")
+			if l := newOptions(opts).logger; l != nil {
+				l.Warn("connect failed: the host or ip is incorrect", "host", host)
+			}
 			return nil, nil
 		}
 		ip = ips[0]
+		// The certificate of a TLS server names the host, not its address.
+		opts = append([]Option{func(o *options) { o.serverName = host }}, opts...)
 	}
-	client, err := connect(ip.String(), port, auth)
+	client, err := connect(ip.String(), port, auth, opts...)
 	if err != nil {
-		if debug {
-			log.Printf("SSDB Client Connect failed:%s:%d error:%v\n", ip, port, err)
-		}
+		client.debugLog("connect failed", "client", client.Id, "addr", net.JoinHostPort(ip.String(), strconv.Itoa(port)), "err", err)
 		go client.RetryConnect()
 		return client, err
 	}
@@ -84,24 +91,80 @@ func Connect(host string, port int, auth string) (*Client, error) {
 	return nil, nil
 }
 
-func connect(ip string, port int, auth string) (*Client, error) {
+func connect(ip string, port int, auth string, opts ...Option) (*Client, error) {
+	opts = append([]Option{WithPassword(auth)}, opts...)
+	return dial(net.JoinHostPort(ip, strconv.Itoa(port)), opts...)
+}
+
+// Dial connects to the SSDB server at addr, in the "host:port" form.
+func Dial(addr string, opts ...Option) (*Client, error) {
+	c, err := dial(addr, opts...)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// dial returns the client even when the connect failed, so that it can be
+// reconnected.
+func dial(addr string, opts ...Option) (*Client, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("ssdb: bad port in address %q", addr)
+	}
+	return newClient(host, port, newOptions(opts))
+}
+
+// DialUnix connects to the SSDB server listening on the unix socket path.
+func DialUnix(path string, opts ...Option) (*Client, error) {
+	c, err := dialUnix(path, opts...)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func dialUnix(path string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	o.network = "unix"
+	return newClient(path, 0, o)
+}
+
+// newClient connects a client to host and port, host is the socket path of
+// a unix client.
+func newClient(host string, port int, o options) (*Client, error) {
 	//log.Printf("SSDB Client Version:%s\n", version)
 	var c Client
-	c.Ip = ip
+	c.Ip = host
 	c.Port = port
-	c.Password = auth
+	c.Password = o.password
+	c.zip = o.zip
+	c.reconnect = o.reconnect
+	c.opts = o
+	c.hooks = o.hooks
 	c.Id = fmt.Sprintf("Cl-%d", time.Now().UnixNano())
 	c.mu = &sync.Mutex{}
+	c.done = make(chan struct{})
 	err := c.Connect()
+	if o.healthCheck > 0 {
+		go c.HealthCheck()
+	}
 	return &c, err
 }
 
+// Debug turns the debug mode of the client on or off. In debug mode the
+// default logger writes the debug messages of the client, a logger set by
+// WithLogger receives them regardless.
 func (c *Client) Debug(flag bool) bool {
-	debug = flag
-	if debug {
-		log.Println("SSDB Client Debug Mode:", debug)
-	}
-	return debug
+	c.debug.Store(flag)
+	c.debugLog("debug mode", "client", c.Id, "debug", flag)
+	return flag
 }
 
 func (c *Client) UseZip(flag bool) {
@@ -109,33 +172,114 @@ func (c *Client) UseZip(flag bool) {
 	//log.Println("SSDB Client Zip Mode:", c.zip)
 }
 
+// SetReconnectPolicy sets how the client reconnects after its connection
+// broke, DefaultReconnectPolicy is used when it is not set.
+func (c *Client) SetReconnectPolicy(p ReconnectPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnect = p
+}
+
+// clone connects a new client with the settings of c.
+func (c *Client) clone() (*Client, error) {
+	c.mu.Lock()
+	o := c.opts
+	o.reconnect = c.reconnect
+	o.hooks = c.hooks
+	c.mu.Unlock()
+	o.password = c.Password
+	o.zip = c.zip
+	o.healthCheck = 0
+	inner, err := newClient(c.Ip, c.Port, o)
+	inner.debug.Store(c.debug.Load())
+	return inner, err
+}
+
+// addr returns the network and address the client connects to.
+func (c *Client) addr() (string, string) {
+	if c.opts.network == "unix" {
+		return "unix", c.Ip
+	}
+	return "tcp", net.JoinHostPort(c.Ip, strconv.Itoa(c.Port))
+}
+
+// dial opens a connection with the dialer of the client, over TLS when the
+// client has a TLS config.
+func (c *Client) dial() (net.Conn, error) {
+	ctx := context.Background()
+	if c.opts.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.dialTimeout)
+		defer cancel()
+	}
+	dialer := c.opts.dialer
+	if dialer == nil {
+		dialer = &net.Dialer{KeepAlive: c.opts.keepAlive}
+	}
+	network, addr := c.addr()
+	sock, err := dialer.DialContext(ctx, network, addr)
+	if err != nil || c.opts.tlsConfig == nil {
+		return sock, err
+	}
+	config := c.opts.tlsConfig
+	if config.ServerName == "" && network != "unix" {
+		config = config.Clone()
+		config.ServerName = c.opts.serverName
+		if config.ServerName == "" {
+			config.ServerName = c.Ip
+		}
+	}
+	tlsSock := tls.Client(sock, config)
+	if err := tlsSock.HandshakeContext(ctx); err != nil {
+		sock.Close()
+		return nil, err
+	}
+	return tlsSock, nil
+}
+
+// Connect opens the connection of the client and authenticates it when the
+// client has a password, it returns the error of the dial or of auth.
 func (c *Client) Connect() error {
-	seconds := 60
-	timeOut := time.Duration(seconds) * time.Second
-	sock, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", c.Ip, c.Port), timeOut)
+	if c.route != nil {
+		return nil
+	}
+	sock, err := c.dial()
 	if err != nil {
-		log.Println("SSDB Client dial failed:", err, c.Id)
+		c.debugLog("dial failed", "client", c.Id, "err", err)
 		return err
 	}
-	c.sock = sock
-	c.Connected = true
-	if c.Retry {
-		log.Printf("Client[%s] retry connect to %s:%d success.", c.Id, c.Ip, c.Port)
-	} else {
-		if debug {
-			log.Printf("Client[%s] connect to %s:%d success. Info:%v\n", c.Id, c.Ip, c.Port, c.sock.LocalAddr())
-		}
+	c.mu.Lock()
+	if c.Closed {
+		c.mu.Unlock()
+		sock.Close()
+		return ErrClosed
 	}
+	cn := newConn(c, sock)
+	c.conn = cn
+	c.Connected = true
+	retry := c.Retry
 	c.Retry = false
-	if !c.init {
-		c.process = make(chan []interface{})
-		c.result = make(chan ClientResult)
-		go c.processDo()
-		c.init = true
+	c.mu.Unlock()
+	if retry {
+		c.logger().Info("reconnected", "client", c.Id, "addr", sock.RemoteAddr())
+	} else {
+		c.debugLog("connected", "client", c.Id, "addr", sock.RemoteAddr(), "local", sock.LocalAddr())
 	}
 
 	if c.Password != "" {
-		c.Auth(c.Password)
+		if err := c.Auth(c.Password); err != nil {
+			// The connection is dropped so that no command runs on it
+			// unauthenticated, Dial closes the client and a reconnect tries
+			// again.
+			c.mu.Lock()
+			if c.conn == cn {
+				c.Connected = false
+			}
+			c.mu.Unlock()
+			cn.fail(err)
+			c.debugLog("auth failed", "client", c.Id, "err", err)
+			return err
+		}
 	}
 
 	return nil
@@ -145,81 +289,115 @@ func (c *Client) KeepAlive() {
 	go c.HealthCheck()
 }
 
+// HealthCheck pings the server at the health check interval of the client,
+// 30s by default, until the client is closed.
 func (c *Client) HealthCheck() {
-	timeout := 30
+	interval := c.opts.healthCheck
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
 	for {
-		if c != nil && c.Connected && !c.Retry && !c.Closed {
-			result, err := c.Do("ping")
+		if c.alive() {
+			err := c.Ping()
 			if err != nil {
-				log.Printf("Client Health Check Failed[%s]:%v\n", c.Id, err)
+				c.logger().Warn("health check failed", "client", c.Id, "err", err)
 			} else {
-				if debug {
-					log.Printf("Client Health Check Success[%s]:%v\n", c.Id, result)
-				}
+				c.debugLog("health check succeeded", "client", c.Id)
 			}
 		}
-		time.Sleep(time.Duration(timeout) * time.Second)
+		select {
+		case <-t.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Ping checks the connection with the ping command.
+func (c *Client) Ping() error {
+	result, err := c.Do("ping")
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 || result[0] != "ok" {
+		return fmt.Errorf("bad ping response:%v", result)
 	}
+	return nil
+}
+
+func (c *Client) alive() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Connected && !c.Retry && !c.Closed
+}
+
+// current returns the connection in use, nil when the client is not connected.
+func (c *Client) current() *conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.Connected || c.Closed {
+		return nil
+	}
+	return c.conn
 }
 
+// RetryConnect reconnects the client as set by its ReconnectPolicy, it
+// returns once connected, closed or given up.
 func (c *Client) RetryConnect() {
-	if !c.Retry {
-		c.mu.Lock()
-		c.Retry = true
-		c.Connected = false
+	c.mu.Lock()
+	if c.Retry || c.Closed {
 		c.mu.Unlock()
-		//log.Printf("Client[%s] retry connect to %s:%d Connected:%v Closed:%v\n", c.Id, c.Ip, c.Port, c.Connected, c.Closed)
-		for {
-			if !c.Connected && !c.Closed {
-				err := c.Connect()
-				if err != nil {
-					log.Printf("Client[%s] Retry connect to %s:%d Failed. Error:%v\n", c.Id, c.Ip, c.Port, err)
-					time.Sleep(5 * time.Second)
-				}
-			} else {
-				log.Printf("Client[%s] Retry connect to %s:%d stop by conn:%v closed:%v\n.", c.Id, c.Ip, c.Port, c.Connected, c.Closed)
-				break
+		return
+	}
+	c.Retry = true
+	c.Connected = false
+	policy := c.reconnect
+	c.mu.Unlock()
+	if m := c.opts.metrics; m != nil {
+		onStateChange := policy.OnStateChange
+		policy.OnStateChange = func(ev ReconnectEvent) {
+			m.ObserveReconnect(ev)
+			if onStateChange != nil {
+				onStateChange(ev)
 			}
 		}
 	}
+	//log.Printf("Client[%s] retry connect to %s:%d Connected:%v Closed:%v\n", c.Id, c.Ip, c.Port, c.Connected, c.Closed)
+	err := policy.run(c.Connect, c.done)
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	c.Retry = false
+	c.mu.Unlock()
+	if err != ErrClosed {
+		c.logger().Error("reconnect gave up", "client", c.Id, "err", err)
+	}
 }
 
 func (c *Client) CheckError(err error) {
-	if err != nil {
-		if !c.Closed {
-			log.Printf("Check Error:%v Retry connect.\n", err)
-			c.sock.Close()
-			go c.RetryConnect()
-		}
-
+	if IsConnError(err) {
+		c.breakConn(c.current(), err)
 	}
 }
 
-func (c *Client) processDo() {
-	for args := range c.process {
-		var timeout uint32 = 0
-		var runArgs []interface{}
-		runId := ""
-		if debug {
-			log.Println("processDo:", args)
-		}
-		switch args[0].(type) {
-		case uint32:
-			timeout = args[0].(uint32)
-			runId = args[1].(string)
-			runArgs = args[2:]
-		default:
-			runId = args[0].(string)
-			runArgs = args[1:]
-		}
-		if debug {
-			log.Println("processDo runArgs:", runArgs, timeout)
-		}
-		result, err := c.do(runArgs, timeout)
-		if !c.isChanClosed(c.result) {
-			c.result <- ClientResult{Id: runId, Data: result, Error: err}
-		}
+// breakConn closes cn and reconnects when cn is still the connection in use.
+func (c *Client) breakConn(cn *conn, err error) {
+	c.mu.Lock()
+	if cn == nil || cn != c.conn || !c.Connected || c.Closed {
+		c.mu.Unlock()
+		return
 	}
+	c.Connected = false
+	c.mu.Unlock()
+	c.logger().Warn("connection broken, reconnecting", "client", c.Id, "err", err)
+	cn.fail(err)
+	go c.RetryConnect()
 }
 
 func ArrayAppendToFirst(src []interface{}, dst []interface{}) []interface{} {
@@ -228,204 +406,153 @@ func ArrayAppendToFirst(src []interface{}, dst []interface{}) []interface{} {
 	return tmp
 }
 
+// Do runs a command. A leading int argument sets the timeout of the call in
+// milliseconds, use DoContext to pass a deadline or a cancellation instead.
 func (c *Client) Do(args ...interface{}) ([]string, error) {
-	if c != nil && c.Connected && !c.Retry && !c.Closed {
-		runId := fmt.Sprintf("%d", time.Now().UnixNano())
-		switch args[0].(type) {
-		case int:
-			timeout := uint32(args[0].(int))
-			args = args[1:]
-			args = ArrayAppendToFirst([]interface{}{runId}, args)
-			args = ArrayAppendToFirst([]interface{}{timeout}, args)
-		default:
-			args = ArrayAppendToFirst([]interface{}{runId}, args)
+	if len(args) > 0 {
+		if timeout, ok := args[0].(int); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Millisecond)
+			defer cancel()
+			return c.DoContext(ctx, args[1:]...)
 		}
-		if debug {
-			log.Println("Do:", args)
+	}
+	return c.DoContext(context.Background(), args...)
+}
+
+// DoContext runs a command. Commands of concurrent callers are pipelined on
+// the connection. The deadline of ctx is applied to the socket while the
+// command is written, a caller that gives up while waiting for the response
+// returns ctx.Err() at once and its response is read and dropped.
+func (c *Client) DoContext(ctx context.Context, args ...interface{}) ([]string, error) {
+	ns := namespaceFrom(ctx)
+	if ns == "" {
+		return c.doHooked(ctx, args)
+	}
+	args, rule, err := ns.command(args)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doHooked(ctx, args)
+	return ns.response(rule, resp), err
+}
+
+// doHooked runs a command between the hooks.
+func (c *Client) doHooked(ctx context.Context, args []interface{}) ([]string, error) {
+	hs := c.getHooks()
+	if len(hs) == 0 {
+		return c.do(ctx, args)
+	}
+	cmd := newCmd(args)
+	err := hs.process(ctx, cmd, func(ctx context.Context, cmd *Cmd) {
+		start := time.Now()
+		resp, err := c.do(ctx, cmd.args())
+		cmd.done(resp, err, time.Since(start))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Resp, nil
+}
+
+// do runs a command without the hooks.
+func (c *Client) do(ctx context.Context, args []interface{}) ([]string, error) {
+	cmd := cmdName(args)
+	if c.route != nil {
+		if !c.alive() {
+			return nil, connError(cmd, ErrClosed)
 		}
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("Recovered in Do", r)
-			}
-		}()
-		c.process <- args
-		for result := range c.result {
-			if result.Id == runId {
-				return result.Data, result.Error
-			} else {
-				c.result <- result
-			}
+		return c.route.do(withoutNamespace(ctx), args)
+	}
+	req := newRequest(ctx, args)
+	if c.alive() {
+		c.debugLog("do", "client", c.Id, "args", args)
+		if err := c.roundTrip(ctx, []*request{req}); err != nil {
+			c.observe(req, nil, err)
+			return nil, connError(cmd, err)
+		}
+		resp, err := req.wait(ctx)
+		c.observe(req, resp, err)
+		if err != nil {
+			return nil, connError(cmd, err)
 		}
+		return resp, nil
 	}
-	return nil, fmt.Errorf("Connection has closed.")
+	c.observe(req, nil, ErrClosed)
+	return nil, connError(cmd, ErrClosed)
 }
 
 func (c *Client) BatchAppend(args ...interface{}) {
-	if c != nil && c.Connected && !c.Retry && !c.Closed {
+	if c.alive() {
 		c.batchBuf = append(c.batchBuf, args)
 	}
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println("Recovered in BatchAppend", r)
+			c.logger().Error("recovered in BatchAppend", "client", c.Id, "panic", r)
 		}
 	}()
 }
 
 func (c *Client) Exec() ([][]string, error) {
-	if c != nil && c.Connected && !c.Retry && !c.Closed {
+	return c.ExecContext(context.Background())
+}
+
+func (c *Client) ExecContext(ctx context.Context) ([][]string, error) {
+	if c.alive() {
 		if len(c.batchBuf) > 0 {
-			runId := fmt.Sprintf("%d", time.Now().UnixNano())
 			firstElement := c.batchBuf[0]
 			jsonStr, err := json.Marshal(&c.batchBuf)
 			if err != nil {
 				return [][]string{}, fmt.Errorf("Exec Json Error:%v", err)
 			}
-			args := []interface{}{"batchexec", string(jsonStr)}
-			args = ArrayAppendToFirst([]interface{}{runId}, args)
 			c.batchBuf = c.batchBuf[:0]
-			c.process <- args
-			for result := range c.result {
-				if result.Id == runId {
-					if len(result.Data) == 2 && result.Data[0] == "ok" {
-						var resp [][]string
-						if firstElement[0] != "async" {
-							err := json.Unmarshal([]byte(result.Data[1]), &resp)
-							if err != nil {
-								return [][]string{}, fmt.Errorf("Batch Json Error:%v", err)
-							}
-						}
-						return resp, result.Error
-					} else {
-						return [][]string{}, result.Error
+			result, err := c.DoContext(ctx, "batchexec", string(jsonStr))
+			if err != nil {
+				return [][]string{}, err
+			}
+			if len(result) == 2 && result[0] == "ok" {
+				var resp [][]string
+				if firstElement[0] != "async" {
+					err := json.Unmarshal([]byte(result[1]), &resp)
+					if err != nil {
+						return [][]string{}, fmt.Errorf("Batch Json Error:%v", err)
 					}
-
-				} else {
-					c.result <- result
 				}
+				return resp, nil
 			}
+			return [][]string{}, nil
 		} else {
 			return [][]string{}, fmt.Errorf("Batch Exec Error:No Batch Command found.")
 		}
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Recovered in Exec", r)
-		}
-	}()
-	return nil, fmt.Errorf("Connection has closed.")
-}
-
-func (c *Client) do(args []interface{}, timeout uint32) ([]string, error) {
-	if c.Connected {
-		signal := make(chan ClientProcessResult)
-		if timeout > 0 {
-			if debug {
-				log.Println("Do setTimeout:", timeout)
-			}
-			go c.setTimeout(timeout, signal)
-		}
-
-		go func() {
-			if !c.isChanClosed(signal) {
-				var cpr ClientProcessResult
-				err := c.Send(args)
-				if err != nil {
-					if debug {
-						log.Printf("SSDB Client[%s] Do Send Error:%v Data:%v\n", c.Id, err, args)
-					}
-					c.CheckError(err)
-					cpr.Data = nil
-					cpr.Error = err
-				}
-				resp, err := c.recv()
-				if err != nil {
-					if debug {
-						log.Printf("SSDB Client[%s] Do Receive Error:%v Data:%v\n", c.Id, err, args)
-					}
-					c.CheckError(err)
-					cpr.Data = nil
-					cpr.Error = err
-				}
-				cpr.Data = resp
-				cpr.Error = nil
-				if !c.isChanClosed(signal) {
-					signal <- cpr
-				}
-
-			}
-		}()
-		for result := range signal {
-			if debug {
-				log.Println("Do Receive:", result)
-			}
-			close(signal)
-			return result.Data, result.Error
-		}
-	}
-	return nil, fmt.Errorf("lost ssdb connection")
+	return nil, connError("batchexec", ErrClosed)
 }
 
-func (c *Client) isChanClosed(ch interface{}) bool {
-	if reflect.TypeOf(ch).Kind() != reflect.Chan {
-		panic("only channels!")
+// contextError reports the error of ctx when err was caused by the deadline
+// or the cancellation of ctx.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
-	cptr := *(*uintptr)(unsafe.Pointer(
-		unsafe.Pointer(uintptr(unsafe.Pointer(&ch)) + unsafe.Sizeof(uint(0))),
-	))
-	cptr += unsafe.Sizeof(uint(0)) * 2
-	cptr += unsafe.Sizeof(unsafe.Pointer(uintptr(0)))
-	cptr += unsafe.Sizeof(uint16(0))
-	return *(*uint32)(unsafe.Pointer(cptr)) > 0
-}
-
-func (c *Client) setTimeout(timeout uint32, signal chan ClientProcessResult) {
-	boom := time.After(time.Duration(timeout) * time.Millisecond)
-	for {
-		select {
-		case <-boom:
-			if !c.isChanClosed(signal) {
-				var cpr ClientProcessResult
-				cpr.Data = nil
-				cpr.Error = fmt.Errorf("Operation timeout in %d ms.", timeout)
-				signal <- cpr
-			}
-			return
-		default:
-			time.Sleep(50 * time.Millisecond)
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		if _, ok := ctx.Deadline(); ok {
+			return context.DeadlineExceeded
 		}
 	}
+	return err
 }
 
 func (c *Client) ProcessCmd(cmd string, args []interface{}) (interface{}, error) {
-	if c.Connected {
-		args = ArrayAppendToFirst([]interface{}{cmd}, args)
-		runId := fmt.Sprintf("%d", time.Now().UnixNano())
-		args = ArrayAppendToFirst([]interface{}{runId}, args)
-		if debug {
-			log.Println("ProcessCmd:", args)
-		}
-		var err error
-		c.process <- args
-		var resResult ClientResult
-		for result := range c.result {
-			if result.Id == runId {
-				resResult = result
-				break
-			} else {
-				c.result <- result
+	return c.ProcessCmdContext(context.Background(), cmd, args)
+}
 
-			}
-		}
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("Recovered in ProcessCmd", r)
-			}
-		}()
-		if resResult.Error != nil {
-			return nil, resResult.Error
+func (c *Client) ProcessCmdContext(ctx context.Context, cmd string, args []interface{}) (interface{}, error) {
+	if c.alive() {
+		args = ArrayAppendToFirst([]interface{}{cmd}, args)
+		c.debugLog("process command", "client", c.Id, "args", args)
+		resp, err := c.DoContext(ctx, args...)
+		if err != nil {
+			return nil, err
 		}
-
-		resp := resResult.Data
 		if len(resp) == 2 && resp[0] == "ok" {
 			switch cmd {
 			case "set", "del":
@@ -443,7 +570,7 @@ func (c *Client) ProcessCmd(cmd string, args []interface{}) (interface{}, error)
 			}
 
 		} else if len(resp) == 1 && resp[0] == "not_found" {
-			return nil, fmt.Errorf("%v", resp[0])
+			return nil, responseError(cmd, resp)
 		} else {
 			if len(resp) >= 1 && resp[0] == "ok" {
 				//fmt.Println("Process:",args,resp)
@@ -461,204 +588,361 @@ func (c *Client) ProcessCmd(cmd string, args []interface{}) (interface{}, error)
 				}
 			}
 		}
-		if len(resp) == 2 && strings.Contains(resp[1], "connection") {
-			c.sock.Close()
-			go c.RetryConnect()
-		}
-		log.Printf("SSDB Client Error Response:%v args:%v Error:%v", resp, args, err)
-		return nil, fmt.Errorf("bad response:%v args:%v", resp, args)
+		c.debugLog("error response", "client", c.Id, "args", args, "resp", resp)
+		return nil, responseError(cmd, resp)
 	} else {
-		return nil, fmt.Errorf("lost connection")
+		return nil, connError(cmd, ErrClosed)
+	}
+}
+
+// call runs cmd and returns the data of an ok response.
+func (c *Client) call(ctx context.Context, cmd string, args ...interface{}) ([]string, error) {
+	args = ArrayAppendToFirst([]interface{}{cmd}, args)
+	resp, err := c.DoContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) > 0 && resp[0] == "ok" {
+		return resp[1:], nil
+	}
+	if len(resp) == 1 && resp[0] == "not_found" {
+		return nil, responseError(cmd, resp)
+	}
+	c.debugLog("error response", "client", c.Id, "args", args, "resp", resp)
+	return nil, responseError(cmd, resp)
+}
+
+func parseInt(data []string) (int64, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("empty response")
+	}
+	return strconv.ParseInt(data[0], 10, 64)
+}
+
+func parseFloat(data []string) (float64, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("empty response")
+	}
+	return strconv.ParseFloat(data[0], 64)
+}
+
+func parseString(data []string) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	return data[0], nil
+}
+
+func parseBool(data []string) (bool, error) {
+	if len(data) == 0 {
+		return false, fmt.Errorf("empty response")
 	}
+	return data[0] == "1", nil
 }
 
-func (c *Client) Auth(pwd string) (interface{}, error) {
-	return c.Do("auth", pwd)
-	//return c.ProcessCmd("auth",params)
+// parseMap converts key/value pairs of a response into a map.
+func parseMap(data []string) (map[string]string, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("bad response size:%d", len(data))
+	}
+	list := make(map[string]string, len(data)/2)
+	for i := 0; i < len(data); i += 2 {
+		list[data[i]] = data[i+1]
+	}
+	return list, nil
+}
+
+func (c *Client) Auth(pwd string) error {
+	return c.AuthContext(context.Background(), pwd)
+}
+
+func (c *Client) AuthContext(ctx context.Context, pwd string) error {
+	_, err := c.call(ctx, "auth", pwd)
+	return err
+}
+
+func (c *Client) Set(key string, val string) error {
+	return c.SetContext(context.Background(), key, val)
+}
+
+func (c *Client) SetContext(ctx context.Context, key string, val string) error {
+	_, err := c.call(ctx, "set", key, val)
+	return err
 }
 
-func (c *Client) Set(key string, val string) (interface{}, error) {
-	params := []interface{}{key, val}
-	return c.ProcessCmd("set", params)
+func (c *Client) Get(key string) (string, error) {
+	return c.GetContext(context.Background(), key)
 }
 
-func (c *Client) Get(key string) (interface{}, error) {
-	params := []interface{}{key}
-	return c.ProcessCmd("get", params)
+func (c *Client) GetContext(ctx context.Context, key string) (string, error) {
+	data, err := c.call(ctx, "get", key)
+	if err != nil {
+		return "", err
+	}
+	return parseString(data)
+}
+
+func (c *Client) Del(key string) error {
+	return c.DelContext(context.Background(), key)
+}
+
+func (c *Client) DelContext(ctx context.Context, key string) error {
+	_, err := c.call(ctx, "del", key)
+	return err
 }
 
-func (c *Client) Del(key string) (interface{}, error) {
-	params := []interface{}{key}
-	return c.ProcessCmd("del", params)
+func (c *Client) SetX(key string, val string, ttl int) error {
+	return c.SetXContext(context.Background(), key, val, ttl)
 }
 
-func (c *Client) SetX(key string, val string, ttl int) (interface{}, error) {
-	params := []interface{}{key, val, ttl}
-	return c.ProcessCmd("setx", params)
+func (c *Client) SetXContext(ctx context.Context, key string, val string, ttl int) error {
+	_, err := c.call(ctx, "setx", key, val, ttl)
+	return err
 }
 
-func (c *Client) Scan(start string, end string, limit int) (interface{}, error) {
-	params := []interface{}{start, end, limit}
-	return c.ProcessCmd("scan", params)
+func (c *Client) Scan(start string, end string, limit int) (map[string]string, error) {
+	return c.ScanContext(context.Background(), start, end, limit)
 }
 
-func (c *Client) Expire(key string, ttl int) (interface{}, error) {
-	params := []interface{}{key, ttl}
-	return c.ProcessCmd("expire", params)
+func (c *Client) ScanContext(ctx context.Context, start string, end string, limit int) (map[string]string, error) {
+	data, err := c.call(ctx, "scan", start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseMap(data)
 }
 
-func (c *Client) KeyTTL(key string) (interface{}, error) {
-	params := []interface{}{key}
-	return c.ProcessCmd("ttl", params)
+func (c *Client) Expire(key string, ttl int) (bool, error) {
+	return c.ExpireContext(context.Background(), key, ttl)
+}
+
+func (c *Client) ExpireContext(ctx context.Context, key string, ttl int) (bool, error) {
+	data, err := c.call(ctx, "expire", key, ttl)
+	if err != nil {
+		return false, err
+	}
+	return parseBool(data)
+}
+
+func (c *Client) KeyTTL(key string) (int64, error) {
+	return c.KeyTTLContext(context.Background(), key)
+}
+
+func (c *Client) KeyTTLContext(ctx context.Context, key string) (int64, error) {
+	data, err := c.call(ctx, "ttl", key)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
 }
 
 //set new key if key exists then ignore this operation
-func (c *Client) SetNew(key string, val string) (interface{}, error) {
-	params := []interface{}{key, val}
-	return c.ProcessCmd("setnx", params)
+func (c *Client) SetNew(key string, val string) (bool, error) {
+	return c.SetNewContext(context.Background(), key, val)
+}
+
+func (c *Client) SetNewContext(ctx context.Context, key string, val string) (bool, error) {
+	data, err := c.call(ctx, "setnx", key, val)
+	if err != nil {
+		return false, err
+	}
+	return parseBool(data)
 }
 
 //
-func (c *Client) GetSet(key string, val string) (interface{}, error) {
-	params := []interface{}{key, val}
-	return c.ProcessCmd("getset", params)
+func (c *Client) GetSet(key string, val string) (string, error) {
+	return c.GetSetContext(context.Background(), key, val)
+}
+
+func (c *Client) GetSetContext(ctx context.Context, key string, val string) (string, error) {
+	data, err := c.call(ctx, "getset", key, val)
+	if err != nil {
+		return "", err
+	}
+	return parseString(data)
 }
 
 //incr num to exist number value
-func (c *Client) Incr(key string, val int) (interface{}, error) {
-	params := []interface{}{key, val}
-	return c.ProcessCmd("incr", params)
+func (c *Client) Incr(key string, val int64) (int64, error) {
+	return c.IncrContext(context.Background(), key, val)
+}
+
+func (c *Client) IncrContext(ctx context.Context, key string, val int64) (int64, error) {
+	data, err := c.call(ctx, "incr", key, val)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+func (c *Client) Exists(key string) (bool, error) {
+	return c.ExistsContext(context.Background(), key)
+}
+
+func (c *Client) ExistsContext(ctx context.Context, key string) (bool, error) {
+	data, err := c.call(ctx, "exists", key)
+	if err != nil {
+		return false, err
+	}
+	return parseBool(data)
 }
 
-func (c *Client) Exists(key string) (interface{}, error) {
-	params := []interface{}{key}
-	return c.ProcessCmd("exists", params)
+func (c *Client) HashSet(hash string, key string, val string) error {
+	return c.HashSetContext(context.Background(), hash, key, val)
 }
 
-func (c *Client) HashSet(hash string, key string, val string) (interface{}, error) {
-	params := []interface{}{hash, key, val}
-	return c.ProcessCmd("hset", params)
+func (c *Client) HashSetContext(ctx context.Context, hash string, key string, val string) error {
+	_, err := c.call(ctx, "hset", hash, key, val)
+	return err
 }
 
 // ------  added by Dixen for multi connections Hashset function
 
-func conHelper(chunk []HashData, wg *sync.WaitGroup, c *Client, results []interface{}, errs []error) {
+func conHelper(chunk []HashData, wg *sync.WaitGroup, c *Client, results *[]interface{}, errp *error) {
 	defer wg.Done()
-	fmt.Printf("go - %v\n", time.Now())
+	c.debugLog("MultiHashSet chunk started", "client", c.Id, "size", len(chunk))
 	for _, v := range chunk {
 		params := []interface{}{v.HashName, v.Key, v.Value}
 		res, err := c.ProcessCmd("hset", params)
 		if err != nil {
-			errs = append(errs, err)
+			*errp = err
 			break
 		}
-		results = append(results, res)
+		*results = append(*results, res)
 	}
-	fmt.Printf("so - %v\n", time.Now())
+	c.debugLog("MultiHashSet chunk done", "client", c.Id)
 }
 
 func (c *Client) MultiHashSet(parts []HashData, connNum int) (interface{}, error) {
 	var privatePool []*Client
+	var releases []func()
 	for i := 0; i < connNum-1; i++ {
-		innerClient, _ := Connect(c.Ip, c.Port, c.Password)
+		innerClient, release, err := c.borrow()
+		if err != nil {
+			c.logger().Warn("MultiHashSet borrow failed, using the client", "client", c.Id, "conn", i, "err", err)
+			innerClient, release = c, func() {}
+		}
 		privatePool = append(privatePool, innerClient)
+		releases = append(releases, release)
 	}
 	privatePool = append(privatePool, c)
-	var results []interface{}
-	var errs []error
+	// Each chunk collects its own results and error, they are joined in the
+	// order of the chunks.
+	chunkResults := make([][]interface{}, connNum)
+	errs := make([]error, connNum)
 	var wg sync.WaitGroup
 	wg.Add(connNum)
 	p := len(parts) / connNum
 	for i := 1; i <= connNum; i++ {
 		if i == 1 {
-			go conHelper(parts[:p*i], &wg, privatePool[i-1], results, errs)
+			go conHelper(parts[:p*i], &wg, privatePool[i-1], &chunkResults[i-1], &errs[i-1])
 		} else if i == connNum {
-			go conHelper(parts[p*(i-1):], &wg, privatePool[i-1], results, errs)
+			go conHelper(parts[p*(i-1):], &wg, privatePool[i-1], &chunkResults[i-1], &errs[i-1])
 		} else {
-			go conHelper(parts[p*(i-1):p*i], &wg, privatePool[i-1], results, errs)
+			go conHelper(parts[p*(i-1):p*i], &wg, privatePool[i-1], &chunkResults[i-1], &errs[i-1])
 		}
 
 	}
 	wg.Wait()
-	for _, c := range privatePool[:connNum-1] {
-		c.Close()
+	for _, release := range releases {
+		release()
 	}
-	if len(errs) > 0 {
-		return nil, errs[0]
+	var results []interface{}
+	for i, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunkResults[i]...)
 	}
 	return results, nil
 }
 
-func (c *Client) MultiMode(args [][]interface{}) ([]string, error) {
-	if c.Connected {
-		for _, v := range args {
-			err := c.Send(v)
-			if err != nil {
-				log.Printf("SSDB Client[%s] Do Send Error:%v Data:%v\n", c.Id, err, args)
-				c.CheckError(err)
-				return nil, err
-			}
-		}
-		var resps []string
-		for i := 0; i < len(args); i++ {
-			resp, err := c.recv()
-			if err != nil {
-				log.Printf("SSDB Client[%s] Do Receive Error:%v Data:%v\n", c.Id, err, args)
-				c.CheckError(err)
-				return nil, err
-			}
-			resps = append(resps, strings.Join(resp, ","))
-		}
-		return resps, nil
+func (c *Client) HashGet(hash string, key string) (string, error) {
+	return c.HashGetContext(context.Background(), hash, key)
+}
+
+func (c *Client) HashGetContext(ctx context.Context, hash string, key string) (string, error) {
+	data, err := c.call(ctx, "hget", hash, key)
+	if err != nil {
+		return "", err
 	}
-	return nil, fmt.Errorf("lost connection")
+	return parseString(data)
+}
+
+func (c *Client) HashDel(hash string, key string) error {
+	return c.HashDelContext(context.Background(), hash, key)
+}
+
+func (c *Client) HashDelContext(ctx context.Context, hash string, key string) error {
+	_, err := c.call(ctx, "hdel", hash, key)
+	return err
+}
+
+func (c *Client) HashIncr(hash string, key string, val int64) (int64, error) {
+	return c.HashIncrContext(context.Background(), hash, key, val)
 }
 
-func (c *Client) HashGet(hash string, key string) (interface{}, error) {
-	params := []interface{}{hash, key}
-	return c.ProcessCmd("hget", params)
+func (c *Client) HashIncrContext(ctx context.Context, hash string, key string, val int64) (int64, error) {
+	data, err := c.call(ctx, "hincr", hash, key, val)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
 }
 
-func (c *Client) HashDel(hash string, key string) (interface{}, error) {
-	params := []interface{}{hash, key}
-	return c.ProcessCmd("hdel", params)
+func (c *Client) HashExists(hash string, key string) (bool, error) {
+	return c.HashExistsContext(context.Background(), hash, key)
 }
 
-func (c *Client) HashIncr(hash string, key string, val int) (interface{}, error) {
-	params := []interface{}{hash, key, val}
-	return c.ProcessCmd("hincr", params)
+func (c *Client) HashExistsContext(ctx context.Context, hash string, key string) (bool, error) {
+	data, err := c.call(ctx, "hexists", hash, key)
+	if err != nil {
+		return false, err
+	}
+	return parseBool(data)
 }
 
-func (c *Client) HashExists(hash string, key string) (interface{}, error) {
-	params := []interface{}{hash, key}
-	return c.ProcessCmd("hexists", params)
+func (c *Client) HashSize(hash string) (int64, error) {
+	return c.HashSizeContext(context.Background(), hash)
 }
 
-func (c *Client) HashSize(hash string) (interface{}, error) {
-	params := []interface{}{hash}
-	return c.ProcessCmd("hsize", params)
+func (c *Client) HashSizeContext(ctx context.Context, hash string) (int64, error) {
+	data, err := c.call(ctx, "hsize", hash)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
 }
 
 //search from start to end hashmap name or haskmap key name,except start word
-func (c *Client) HashList(start string, end string, limit int) (interface{}, error) {
-	params := []interface{}{start, end, limit}
-	return c.ProcessCmd("hlist", params)
+func (c *Client) HashList(start string, end string, limit int) ([]string, error) {
+	return c.HashListContext(context.Background(), start, end, limit)
+}
+
+func (c *Client) HashListContext(ctx context.Context, start string, end string, limit int) ([]string, error) {
+	return c.call(ctx, "hlist", start, end, limit)
+}
+
+func (c *Client) HashKeys(hash string, start string, end string, limit int) ([]string, error) {
+	return c.HashKeysContext(context.Background(), hash, start, end, limit)
 }
 
-func (c *Client) HashKeys(hash string, start string, end string, limit int) (interface{}, error) {
-	params := []interface{}{hash, start, end, limit}
-	return c.ProcessCmd("hkeys", params)
+func (c *Client) HashKeysContext(ctx context.Context, hash string, start string, end string, limit int) ([]string, error) {
+	return c.call(ctx, "hkeys", hash, start, end, limit)
 }
 func (c *Client) HashKeysAll(hash string) ([]string, error) {
-	size, err := c.HashSize(hash)
+	return c.HashKeysAllContext(context.Background(), hash)
+}
+
+func (c *Client) HashKeysAllContext(ctx context.Context, hash string) ([]string, error) {
+	size, err := c.HashSizeContext(ctx, hash)
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("DB Hash Size:%d\n", size)
-	hashSize := size.(int64)
+	hashSize := size
 	page_range := 15
 	splitSize := math.Ceil(float64(hashSize) / float64(page_range))
-	log.Printf("DB Hash Size:%d hashSize:%d splitSize:%f\n", size, hashSize, splitSize)
+	c.debugLog("HashKeysAll", "client", c.Id, "hash", hash, "size", size, "pages", splitSize)
 	var range_keys []string
 	for i := 1; i <= int(splitSize); i++ {
 		start := ""
@@ -668,49 +952,47 @@ func (c *Client) HashKeysAll(hash string) ([]string, error) {
 			end = ""
 		}
 
-		val, err := c.HashKeys(hash, start, end, page_range)
+		data, err := c.HashKeysContext(ctx, hash, start, end, page_range)
 		if err != nil {
-			log.Println("HashGetAll Error:", err)
-			continue
-		}
-		if val == nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.logger().Warn("HashKeys failed", "client", c.Id, "hash", hash, "err", err)
 			continue
 		}
-		//log.Println("HashGetAll type:",reflect.TypeOf(val))
-		var data []string
-		if fmt.Sprintf("%v", reflect.TypeOf(val)) == "string" {
-			data = append(data, val.(string))
-		} else {
-			data = val.([]string)
-		}
 
 		if len(data) > 0 {
 			range_keys = append(range_keys, data...)
 		}
 
 	}
-	log.Printf("DB Hash Keys Size:%d\n", len(range_keys))
+	c.debugLog("HashKeysAll done", "client", c.Id, "hash", hash, "keys", len(range_keys))
 	return range_keys, nil
 }
 
 func (c *Client) HashGetAll(hash string) (map[string]string, error) {
-	params := []interface{}{hash}
-	val, err := c.ProcessCmd("hgetall", params)
+	return c.HashGetAllContext(context.Background(), hash)
+}
+
+func (c *Client) HashGetAllContext(ctx context.Context, hash string) (map[string]string, error) {
+	data, err := c.call(ctx, "hgetall", hash)
 	if err != nil {
 		return nil, err
-	} else {
-		return val.(map[string]string), err
 	}
-	return nil, fmt.Errorf("Data has empty.")
+	return parseMap(data)
 }
 
 func (c *Client) HashGetAllLite(hash string) (map[string]string, error) {
-	size, err := c.HashSize(hash)
+	return c.HashGetAllLiteContext(context.Background(), hash)
+}
+
+func (c *Client) HashGetAllLiteContext(ctx context.Context, hash string) (map[string]string, error) {
+	size, err := c.HashSizeContext(ctx, hash)
 	if err != nil {
 		return nil, err
 	}
 	//log.Printf("DB Hash Size:%d\n",size)
-	hashSize := size.(int64)
+	hashSize := size
 	page_range := 20
 	splitSize := math.Ceil(float64(hashSize) / float64(page_range))
 	//log.Printf("DB Hash Size:%d hashSize:%d splitSize:%f\n",size,hashSize,splitSize)
@@ -724,26 +1006,19 @@ func (c *Client) HashGetAllLite(hash string) (map[string]string, error) {
 			end = ""
 		}
 
-		val, err := c.HashKeys(hash, start, end, page_range)
+		data, err := c.HashKeysContext(ctx, hash, start, end, page_range)
 		if err != nil {
-			log.Println("HashGetAll Error:", err)
-			continue
-		}
-		if val == nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.logger().Warn("HashKeys failed", "client", c.Id, "hash", hash, "err", err)
 			continue
 		}
-		//log.Println("HashGetAll type:",reflect.TypeOf(val))
-		var data []string
-		if fmt.Sprintf("%v", reflect.TypeOf(val)) == "string" {
-			data = append(data, val.(string))
-		} else {
-			data = val.([]string)
-		}
 		range_keys = data
 		if len(data) > 0 {
-			result, err := c.HashMultiGet(hash, data)
+			result, err := c.HashMultiGetContext(ctx, hash, data)
 			if err != nil {
-				log.Println("HashGetAll Error:", err)
+				c.logger().Warn("HashMultiGet failed", "client", c.Id, "hash", hash, "err", err)
 			}
 			if result == nil {
 				continue
@@ -759,260 +1034,137 @@ func (c *Client) HashGetAllLite(hash string) (map[string]string, error) {
 }
 
 func (c *Client) HashScan(hash string, start string, end string, limit int) (map[string]string, error) {
-	params := []interface{}{hash, start, end, limit}
-	val, err := c.ProcessCmd("hscan", params)
+	return c.HashScanContext(context.Background(), hash, start, end, limit)
+}
+
+func (c *Client) HashScanContext(ctx context.Context, hash string, start string, end string, limit int) (map[string]string, error) {
+	data, err := c.call(ctx, "hscan", hash, start, end, limit)
 	if err != nil {
 		return nil, err
-	} else {
-		return val.(map[string]string), err
 	}
-
-	return nil, nil
+	return parseMap(data)
 }
 
 func (c *Client) HashRScan(hash string, start string, end string, limit int) (map[string]string, error) {
-	params := []interface{}{hash, start, end, limit}
-	val, err := c.ProcessCmd("hrscan", params)
+	return c.HashRScanContext(context.Background(), hash, start, end, limit)
+}
+
+func (c *Client) HashRScanContext(ctx context.Context, hash string, start string, end string, limit int) (map[string]string, error) {
+	data, err := c.call(ctx, "hrscan", hash, start, end, limit)
 	if err != nil {
 		return nil, err
-	} else {
-		return val.(map[string]string), err
 	}
-	return nil, nil
+	return parseMap(data)
 }
 
-func (c *Client) HashMultiSet(hash string, data map[string]string) (interface{}, error) {
+func (c *Client) HashMultiSet(hash string, data map[string]string) error {
+	return c.HashMultiSetContext(context.Background(), hash, data)
+}
+
+func (c *Client) HashMultiSetContext(ctx context.Context, hash string, data map[string]string) error {
 	params := []interface{}{hash}
 	for k, v := range data {
 		params = append(params, k)
 		params = append(params, v)
 	}
-	return c.ProcessCmd("multi_hset", params)
+	_, err := c.call(ctx, "multi_hset", params...)
+	return err
 }
 
 func (c *Client) HashMultiGet(hash string, keys []string) (map[string]string, error) {
+	return c.HashMultiGetContext(context.Background(), hash, keys)
+}
+
+func (c *Client) HashMultiGetContext(ctx context.Context, hash string, keys []string) (map[string]string, error) {
 	params := []interface{}{hash}
 	for _, v := range keys {
 		params = append(params, v)
 	}
-	val, err := c.ProcessCmd("multi_hget", params)
+	data, err := c.call(ctx, "multi_hget", params...)
 	if err != nil {
 		return nil, err
-	} else {
-		return val.(map[string]string), err
 	}
-	return nil, fmt.Errorf("data has empty")
+	return parseMap(data)
 }
 
-func (c *Client) HashMultiDel(hash string, keys []string) (interface{}, error) {
+func (c *Client) HashMultiDel(hash string, keys []string) error {
+	return c.HashMultiDelContext(context.Background(), hash, keys)
+}
+
+func (c *Client) HashMultiDelContext(ctx context.Context, hash string, keys []string) error {
 	params := []interface{}{hash}
 	for _, v := range keys {
 		params = append(params, v)
 	}
-	return c.ProcessCmd("multi_hdel", params)
+	_, err := c.call(ctx, "multi_hdel", params...)
+	return err
 }
 
-func (c *Client) HashClear(hash string) (interface{}, error) {
-	params := []interface{}{hash}
-	return c.ProcessCmd("hclear", params)
+func (c *Client) HashClear(hash string) (int64, error) {
+	return c.HashClearContext(context.Background(), hash)
+}
+
+func (c *Client) HashClearContext(ctx context.Context, hash string) (int64, error) {
+	data, err := c.call(ctx, "hclear", hash)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
 }
 
 func (c *Client) Zip(data []byte) string {
-	var zipbuf bytes.Buffer
-	w := gzip.NewWriter(&zipbuf)
-	w.Write(data)
-	w.Close()
-	zipbuff := base64.StdEncoding.EncodeToString(zipbuf.Bytes())
-	return zipbuff
+	return string(proto.Zip(data))
 }
 
-func (c *Client) Send(args []interface{}) error {
-	var buf bytes.Buffer
+// encode appends the request of a command to dst.
+func (c *Client) encode(dst []byte, args []interface{}) ([]byte, error) {
+	var err error
 	if c.zip {
-		buf.WriteString("3")
-		buf.WriteByte('\n')
-		buf.WriteString("zip")
-		buf.WriteByte('\n')
-		var zipbuf bytes.Buffer
-		w := gzip.NewWriter(&zipbuf)
-		for _, arg := range args {
-			var s string
-			switch arg := arg.(type) {
-			case string:
-				s = arg
-			case []byte:
-				s = string(arg)
-			case []string:
-				for _, s := range arg {
-					w.Write([]byte(fmt.Sprintf("%d", len(s))))
-					w.Write([]byte("\n"))
-					w.Write([]byte(s))
-					w.Write([]byte("\n"))
-				}
-				continue
-			case int:
-				s = fmt.Sprintf("%d", arg)
-			case int64:
-				s = fmt.Sprintf("%d", arg)
-			case float64:
-				s = fmt.Sprintf("%f", arg)
-			case bool:
-				if arg {
-					s = "1"
-				} else {
-					s = "0"
-				}
-			case nil:
-				s = ""
-			case []interface{}:
-				for _, s := range arg {
-					buf.WriteString(fmt.Sprintf("%d", len(s.(string))))
-					buf.WriteByte('\n')
-					buf.WriteString(s.(string))
-					buf.WriteByte('\n')
-				}
-				continue
-			default:
-				return fmt.Errorf("[%s]zip send bad arguments:%v", c.Id, args)
-			}
-			w.Write([]byte(fmt.Sprintf("%d", len(s))))
-			w.Write([]byte("\n"))
-			w.Write([]byte(s))
-			w.Write([]byte("\n"))
+		n := len(dst)
+		dst, err = proto.AppendZipCommand(dst, args...)
+		if m := c.opts.metrics; m != nil && err == nil {
+			raw, _ := proto.AppendCommand(nil, args...)
+			m.ObserveZip(len(raw), len(dst)-n)
 		}
-		w.Close()
-		zipbuff := base64.StdEncoding.EncodeToString(zipbuf.Bytes())
-		buf.WriteString(fmt.Sprintf("%d", len(zipbuff)))
-		buf.WriteByte('\n')
-		buf.WriteString(zipbuff)
-		buf.WriteByte('\n')
-		buf.WriteByte('\n')
 	} else {
-		for _, arg := range args {
-			var s string
-			switch arg := arg.(type) {
-			case string:
-				s = arg
-			case []byte:
-				s = string(arg)
-			case []string:
-				for _, s := range arg {
-					buf.WriteString(fmt.Sprintf("%d", len(s)))
-					buf.WriteByte('\n')
-					_, err := buf.WriteString(s)
-					if err != nil {
-						log.Println("Write String Error:", err)
-					}
-					buf.WriteByte('\n')
-				}
-				continue
-			case int:
-				s = fmt.Sprintf("%d", arg)
-			case int64:
-				s = fmt.Sprintf("%d", arg)
-			case float64:
-				s = fmt.Sprintf("%f", arg)
-			case bool:
-				if arg {
-					s = "1"
-				} else {
-					s = "0"
-				}
-			case nil:
-				s = ""
-			case []interface{}:
-				for _, s := range arg {
-					buf.WriteString(fmt.Sprintf("%d", len(s.(string))))
-					buf.WriteByte('\n')
-					buf.WriteString(s.(string))
-					buf.WriteByte('\n')
-				}
-				continue
-			default:
-				return fmt.Errorf("[%s]public send bad arguments:%v type:%v", c.Id, args, arg)
-			}
-			buf.WriteString(fmt.Sprintf("%d", len(s)))
-			buf.WriteByte('\n')
-			buf.WriteString(s)
-			buf.WriteByte('\n')
-		}
-		buf.WriteByte('\n')
+		dst, err = proto.AppendCommand(dst, args...)
 	}
-	tmpBuf := buf.Bytes()
-	_, err := c.sock.Write(tmpBuf)
-	return err
-}
-
-func (c *Client) send(args []interface{}) error {
-	var buf bytes.Buffer
-	for _, arg := range args {
-		var s string
-		switch arg := arg.(type) {
-		case string:
-			s = arg
-		case []byte:
-			s = string(arg)
-		case []string:
-			for _, s := range arg {
-				buf.WriteString(fmt.Sprintf("%d", len(s)))
-				buf.WriteByte('\n')
-				buf.WriteString(s)
-				buf.WriteByte('\n')
-			}
-			continue
-		case int:
-			s = fmt.Sprintf("%d", arg)
-		case int64:
-			s = fmt.Sprintf("%d", arg)
-		case float64:
-			s = fmt.Sprintf("%f", arg)
-		case bool:
-			if arg {
-				s = "1"
-			} else {
-				s = "0"
-			}
-		case []interface{}:
-			for _, s := range arg {
-				buf.WriteString(fmt.Sprintf("%d", len(s.(string))))
-				buf.WriteByte('\n')
-				buf.WriteString(s.(string))
-				buf.WriteByte('\n')
-			}
-			continue
-		case nil:
-			s = ""
-		default:
-			return fmt.Errorf("private send bad arguments:%v", args)
-		}
-		buf.WriteString(fmt.Sprintf("%d", len(s)))
-		buf.WriteByte('\n')
-		buf.WriteString(s)
-		buf.WriteByte('\n')
+	if err != nil {
+		return dst, fmt.Errorf("[%s]send bad arguments:%v %v", c.Id, args, err)
 	}
-	buf.WriteByte('\n')
-	_, err := c.sock.Write(buf.Bytes())
-	return err
+	return dst, nil
 }
 
-func (c *Client) batchSubSend(wg *sync.WaitGroup, batchArgs [][]interface{}) error {
+func (c *Client) batchSubSend(ctx context.Context, wg *sync.WaitGroup, cmds []*Cmd) error {
 	defer wg.Done()
-	for _, args := range batchArgs {
+	for _, cmd := range cmds {
 		//sometime will request loss.
 		/*err := c.send(args)
 		if err != nil {
 			log.Println("batchSubSend:", args, err)
 		}
 		time.Sleep(100 * time.Microsecond)*/
-		_, err := c.Do(args)
+		start := time.Now()
+		resp, err := c.do(ctx, cmd.args())
+		cmd.done(resp, err, time.Since(start))
 		if err != nil {
-			log.Println("batchSubSend:", args, err)
+			c.logger().Warn("BatchSend command failed", "client", c.Id, "cmd", cmd.Name, "err", err)
 		}
 	}
 	return nil
 }
 
+// BatchSend runs the commands over several connections, the errors of the
+// commands are logged.
 func (c *Client) BatchSend(batchArgs [][]interface{}) error {
+	cmds := make([]*Cmd, len(batchArgs))
+	for i, args := range batchArgs {
+		cmds[i] = newCmd(args)
+	}
+	return c.getHooks().processPipeline(context.Background(), cmds, c.batchSend)
+}
+
+func (c *Client) batchSend(ctx context.Context, batchArgs []*Cmd) error {
 	var privatePool []*Client
 	wg := &sync.WaitGroup{}
 	splitSize := 2000
@@ -1021,7 +1173,7 @@ func (c *Client) BatchSend(batchArgs [][]interface{}) error {
 		connNum = 1
 	}
 
-	var splitArgs [][][]interface{}
+	var splitArgs [][]*Cmd
 
 	if len(batchArgs) >= splitSize {
 		pics := int(len(batchArgs) / splitSize)
@@ -1043,186 +1195,60 @@ func (c *Client) BatchSend(batchArgs [][]interface{}) error {
 		splitArgs = append(splitArgs, batchArgs)
 	}
 	connNum = len(splitArgs)
-	if debug {
-		log.Printf("BatchSend Total:%d Connection:%d ip:%v port:%v\n", len(batchArgs), connNum, c.Ip, c.Port)
-	}
+	c.debugLog("BatchSend", "client", c.Id, "commands", len(batchArgs), "conns", connNum)
+	var releases []func()
 	for i := 0; i < connNum; i++ {
-		innerClient, err := Connect(c.Ip, c.Port, c.Password)
+		innerClient, release, err := c.borrow()
 		if err != nil {
-			log.Printf("BatchSend[%v]:%v\n", i, err)
+			c.logger().Warn("BatchSend borrow failed, using the client", "client", c.Id, "conn", i, "err", err)
+			innerClient, release = c, func() {}
 		}
 		privatePool = append(privatePool, innerClient)
+		releases = append(releases, release)
 		//result,err := innerClient.Do("ping")
 	}
 	wg.Add(connNum)
 	for idx, args := range splitArgs {
-		privatePool[idx].batchSubSend(wg, args)
+		privatePool[idx].batchSubSend(ctx, wg, args)
 	}
 	wg.Wait()
-	for _, conn := range privatePool {
-		conn.Close()
+	for _, release := range releases {
+		release()
 	}
 	return nil
 }
 
-func (c *Client) Recv() ([]string, error) {
-	return c.recv()
-}
-
-func (c *Client) recv() ([]string, error) {
-	var tmp [102400]byte
-	for {
-		resp := c.parse()
-		if resp == nil || len(resp) > 0 {
-			//log.Println("SSDB Receive:",resp)
-			if len(resp) > 0 && resp[0] == "zip" {
-				//log.Println("SSDB Receive Zip\n",resp)
-				zipData, err := base64.StdEncoding.DecodeString(resp[1])
-				if err != nil {
-					return nil, err
-				}
-				resp = c.tranfUnZip(zipData)
-			}
-			return resp, nil
-		}
-		n, err := c.sock.Read(tmp[0:])
-		if err != nil {
-			return nil, err
-		}
-		c.recv_buf.Write(tmp[0:n])
-	}
-}
-
-func (c *Client) parse() []string {
-	resp := []string{}
-	buf := c.recv_buf.Bytes()
-	var Idx, offset int
-	Idx = 0
-	offset = 0
-	for {
-		if len(buf) < offset {
-			break
-		}
-		Idx = bytes.IndexByte(buf[offset:], '\n')
-		if Idx == -1 {
-			break
-		}
-		p := buf[offset : offset+Idx]
-		offset += Idx + 1
-		//fmt.Printf("> [%s]\n", p);
-		if len(p) == 0 || (len(p) == 1 && p[0] == '\r') {
-			if len(resp) == 0 {
-				continue
-			} else {
-				c.recv_buf.Next(offset)
-				return resp
-			}
-		}
-		pIdx := strings.Replace(strconv.Quote(string(p)), `"`, ``, -1)
-		size, err := strconv.Atoi(pIdx)
-		if err != nil || size < 0 {
-			//log.Printf("SSDB Parse Error:%v data:%v\n",err,pIdx)
-			return nil
-		}
-		//fmt.Printf("packet size:%d\n",size);
-		if offset+size >= c.recv_buf.Len() {
-			//tmpLen := offset+size
-			//fmt.Printf("buf size too big:%d > buf len:%d\n",tmpLen,c.recv_buf.Len());
-			break
-		}
-
-		v := buf[offset : offset+size]
-		resp = append(resp, string(v))
-		offset += size + 1
-	}
-
-	//fmt.Printf("buf.size: %d packet not ready...\n", len(buf))
-	return []string{}
-}
-
-//this function for transfer data only use.
-func (c *Client) tranfUnZip(data []byte) []string {
-	var buf bytes.Buffer
-	buf.Write(data)
-	zipReader, err := gzip.NewReader(&buf)
-	if err != nil {
-		log.Println("[ERROR] New gzip reader:", err)
-	}
-	defer zipReader.Close()
-
-	zipData, err := ioutil.ReadAll(zipReader)
-	if err != nil {
-		fmt.Println("[ERROR] ReadAll:", err)
-		return nil
-	}
-	var resp []string
-
-	if zipData != nil {
-		Idx := 0
-		offset := 0
-		hiIdx := 0
-		for {
-			Idx = bytes.IndexByte(zipData, '\n')
-			if Idx == -1 {
-				break
-			}
-			p := string(zipData[:Idx])
-			//fmt.Println("p:[",p,"]\n")
-			size, err := strconv.Atoi(string(p))
-			if err != nil || size < 0 {
-				zipData = zipData[Idx+1:]
-				continue
-			} else {
-				offset = Idx + 1 + size
-				hiIdx = size + Idx + 1
-				resp = append(resp, string(zipData[Idx+1:hiIdx]))
-				//fmt.Printf("data:[%s] size:%d Idx:%d\n",str,size,Idx+1)
-				zipData = zipData[offset:]
-			}
-
-		}
-	}
-	return resp
+func (cn *conn) recv() ([]string, error) {
+	return cn.rd.ReadStrings()
 }
 
 func (c *Client) UnZip(data string) ([]byte, error) {
-	var buf bytes.Buffer
-	zipData, err := base64.StdEncoding.DecodeString(data)
-	if err != nil {
-		return []byte{}, err
-	}
-	buf.Write(zipData)
-	zipReader, err := gzip.NewReader(&buf)
-	if err != nil {
-		log.Println("[ERROR] New gzip reader:", err)
-	}
-	defer zipReader.Close()
-
-	unzipData, err := ioutil.ReadAll(zipReader)
-	if err != nil {
-		fmt.Println("[ERROR] ReadAll:", err)
-		return []byte{}, err
-	}
-	buf.Reset()
-	return unzipData, nil
+	return proto.Unzip([]byte(data))
 }
 
 // Close The Client Connection
 func (c *Client) Close() error {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println("Recovered in Close", r)
+			c.logger().Error("recovered in Close", "client", c.Id, "panic", r)
 		}
 	}()
-	if c != nil && !c.Closed {
+	if c != nil {
 		c.mu.Lock()
+		if c.Closed {
+			c.mu.Unlock()
+			return nil
+		}
 		c.Connected = false
 		c.Closed = true
+		cn := c.conn
+		if c.done != nil {
+			close(c.done)
+		}
 		c.mu.Unlock()
-		if c.process != nil {
-			close(c.process)
+		if cn != nil {
+			cn.fail(ErrClosed)
 		}
-		c.sock.Close()
 		c = nil
 	}
 
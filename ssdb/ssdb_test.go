@@ -0,0 +1,170 @@
+package ssdb_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// newServer starts a fake server closed at the end of the test.
+func newServer(t *testing.T) *ssdbtest.Server {
+	t.Helper()
+	srv, err := ssdbtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// dial connects to srv, the client is closed at the end of the test.
+func dial(t *testing.T, srv *ssdbtest.Server, opts ...ssdb.Option) *ssdb.Client {
+	t.Helper()
+	c, err := ssdb.Dial(srv.Addr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// testKV runs the KV and hash helpers on c.
+func testKV(t *testing.T, c *ssdb.Client) {
+	t.Helper()
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, err := c.Get("a"); err != nil || v != "1" {
+		t.Fatalf("Get = %q, %v, want 1", v, err)
+	}
+	if n, err := c.Incr("a", 2); err != nil || n != 3 {
+		t.Fatalf("Incr = %d, %v, want 3", n, err)
+	}
+	if err := c.Del("a"); err != nil {
+		t.Fatalf("Del: %v", err)
+	}
+	if _, err := c.Get("a"); !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("Get of a deleted key: %v, want ErrNotFound", err)
+	}
+	if err := c.HashSet("h", "f", "v"); err != nil {
+		t.Fatalf("HashSet: %v", err)
+	}
+	if v, err := c.HashGet("h", "f"); err != nil || v != "v" {
+		t.Fatalf("HashGet = %q, %v, want v", v, err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	srv := newServer(t)
+	testKV(t, dial(t, srv))
+}
+
+func TestClientZip(t *testing.T) {
+	srv := newServer(t)
+	testKV(t, dial(t, srv, ssdb.WithZip(true)))
+}
+
+func TestConnect(t *testing.T) {
+	srv := newServer(t)
+	srv.SetPassword("secret")
+	c, err := ssdb.Connect(srv.Host(), srv.Port(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	testKV(t, c)
+}
+
+func TestDialAuthError(t *testing.T) {
+	srv := newServer(t)
+	srv.SetPassword("secret")
+	c, err := ssdb.Dial(srv.Addr, ssdb.WithPassword("wrong"))
+	if err == nil {
+		c.Close()
+		t.Fatal("Dial with a wrong password succeeded")
+	}
+	var e *ssdb.Error
+	if !errors.As(err, &e) || e.Cmd != "auth" {
+		t.Fatalf("Dial: %v, want the auth error", err)
+	}
+}
+
+func TestProcessCmd(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	if ok, err := c.ProcessCmd("set", []interface{}{"k", "v"}); err != nil || ok != true {
+		t.Fatalf("ProcessCmd(set) = %v, %v", ok, err)
+	}
+	if v, err := c.ProcessCmd("get", []interface{}{"k"}); err != nil || v != "v" {
+		t.Fatalf("ProcessCmd(get) = %v, %v", v, err)
+	}
+	c.Close()
+	if _, err := c.ProcessCmd("get", []interface{}{"k"}); !errors.Is(err, ssdb.ErrClosed) {
+		t.Fatalf("ProcessCmd on a closed client: %v, want ErrClosed", err)
+	}
+}
+
+func TestMultiHashSet(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	var parts []ssdb.HashData
+	for i := 0; i < 10; i++ {
+		parts = append(parts, ssdb.HashData{HashName: "h", Key: fmt.Sprint(i), Value: "v"})
+	}
+	res, err := c.MultiHashSet(parts, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results, ok := res.([]interface{}); !ok || len(results) != len(parts) {
+		t.Fatalf("MultiHashSet = %v, want %d results", res, len(parts))
+	}
+	srv.AddFault("hset", ssdbtest.Fault{Response: []string{"error", "server busy"}, Times: 1})
+	if _, err := c.MultiHashSet(parts, 3); err == nil {
+		t.Fatal("MultiHashSet dropped the error of a chunk")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	srv := newServer(t)
+	c := dial(t, srv)
+	p := c.Pipeline()
+	p.Append("set", "a", "1")
+	p.Append("get", "a")
+	p.Append("get", "missing")
+	resps, err := p.Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resps) != 3 || resps[1][1] != "1" || resps[2][0] != ssdb.StatusNotFound {
+		t.Fatalf("Exec = %q", resps)
+	}
+}
+
+func TestUnixClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssdb.sock")
+	srv, err := ssdbtest.NewUnixServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	c, err := ssdb.DialUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	testKV(t, c)
+
+	var u *ssdb.UnixClient
+	if u, err = ssdb.UnixConnect(srv.Host(), srv.Port(), ""); err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+	if v, err := u.HashGet("h", "f"); err != nil || v != "v" {
+		t.Fatalf("HashGet = %q, %v, want v", v, err)
+	}
+}
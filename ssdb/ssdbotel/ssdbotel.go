@@ -0,0 +1,253 @@
+// Package ssdbotel traces the commands of ssdb clients with OpenTelemetry.
+//
+//	db, err := ssdb.Dial("127.0.0.1:8888")
+//	ssdbotel.InstrumentTracing(db)
+//
+// Every command gets a client span named after the command, with the
+// db.system, db.operation, db.statement, server.address, server.port and
+// db.response.status_code attributes. Pipelines, MultiMode and BatchSend get
+// one span for all their commands.
+//
+// db.statement holds the command name and its keys, the values are replaced
+// with "?" unless WithRedactor says otherwise. The password of auth is never
+// exported.
+package ssdbotel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer.
+const ScopeName = "github.com/matishsiao/gossdb/ssdb/ssdbotel"
+
+// Option configures the hook created by NewHook.
+type Option func(*config)
+
+// Redactor returns the arguments of a command as they appear in the
+// db.statement attribute. The arguments of auth are always replaced with
+// "?".
+type Redactor func(cmd string, args []interface{}) []interface{}
+
+type config struct {
+	provider  trace.TracerProvider
+	statement bool
+	redact    Redactor
+	attrs     []attribute.KeyValue
+}
+
+// WithTracerProvider creates the spans with tp instead of the global
+// provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = tp
+	}
+}
+
+// WithDBStatement sets whether the spans have a db.statement attribute with
+// the command and its arguments, true by default.
+func WithDBStatement(on bool) Option {
+	return func(c *config) {
+		c.statement = on
+	}
+}
+
+// WithRedactor passes the arguments through redact before they are written
+// to the db.statement attribute instead of RedactValues, see RedactArgs. A
+// nil redact exports the values.
+func WithRedactor(redact Redactor) Option {
+	return func(c *config) {
+		c.redact = redact
+	}
+}
+
+// WithPeer sets the server.address and server.port attributes. port 0 means
+// host is the path of a unix socket.
+func WithPeer(host string, port int) Option {
+	return func(c *config) {
+		if port == 0 {
+			c.attrs = append(c.attrs,
+				attribute.String("server.address", host),
+				attribute.String("network.transport", "unix"))
+			return
+		}
+		c.attrs = append(c.attrs,
+			attribute.String("server.address", host),
+			attribute.Int("server.port", port))
+	}
+}
+
+// WithAttributes adds attrs to every span.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// RedactValues is the default Redactor, it keeps the keys and names of the
+// commands known by ssdb.KeyIndexes and replaces the other arguments with
+// "?".
+func RedactValues(cmd string, args []interface{}) []interface{} {
+	keys, _ := ssdb.KeyIndexes(cmd, args)
+	out := make([]interface{}, len(args))
+	for i := range out {
+		out[i] = "?"
+	}
+	for _, i := range keys {
+		out[i] = args[i]
+	}
+	return out
+}
+
+// RedactArgs is a Redactor that keeps the first n arguments, usually the
+// keys, and replaces the others with "?".
+func RedactArgs(n int) Redactor {
+	return func(cmd string, args []interface{}) []interface{} {
+		out := make([]interface{}, len(args))
+		for i, arg := range args {
+			if i < n {
+				out[i] = arg
+			} else {
+				out[i] = "?"
+			}
+		}
+		return out
+	}
+}
+
+// InstrumentTracing adds a tracing hook to c, the peer attributes are taken
+// from c.Ip and c.Port.
+func InstrumentTracing(c *ssdb.Client, opts ...Option) {
+	opts = append([]Option{WithPeer(c.Ip, c.Port)}, opts...)
+	c.AddHook(NewHook(opts...))
+}
+
+// NewHook returns a hook that traces the commands, use it in
+// ssdb.WithHooks, e.g. in ssdb.PoolConfig.Options.
+func NewHook(opts ...Option) ssdb.Hook {
+	cfg := config{statement: true, redact: RedactValues}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.provider == nil {
+		cfg.provider = otel.GetTracerProvider()
+	}
+	return &hook{
+		tracer: cfg.provider.Tracer(ScopeName),
+		cfg:    cfg,
+		attrs:  append([]attribute.KeyValue{attribute.String("db.system", "ssdb")}, cfg.attrs...),
+	}
+}
+
+type hook struct {
+	tracer trace.Tracer
+	cfg    config
+	attrs  []attribute.KeyValue
+}
+
+func (h *hook) BeforeProcess(ctx context.Context, cmd *ssdb.Cmd) (context.Context, error) {
+	attrs := append(h.attrs[:len(h.attrs):len(h.attrs)], attribute.String("db.operation", cmd.Name))
+	if h.cfg.statement {
+		attrs = append(attrs, attribute.String("db.statement", h.statement(cmd)))
+	}
+	ctx, _ = h.tracer.Start(ctx, cmd.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, nil
+}
+
+func (h *hook) AfterProcess(ctx context.Context, cmd *ssdb.Cmd) error {
+	span := trace.SpanFromContext(ctx)
+	end(span, cmd)
+	return nil
+}
+
+func (h *hook) BeforeProcessPipeline(ctx context.Context, cmds []*ssdb.Cmd) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name
+	}
+	attrs := append(h.attrs[:len(h.attrs):len(h.attrs)],
+		attribute.String("db.operation", "pipeline"),
+		attribute.StringSlice("db.ssdb.commands", names),
+		attribute.Int("db.ssdb.num_cmd", len(cmds)))
+	if h.cfg.statement {
+		stmts := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			stmts[i] = h.statement(cmd)
+		}
+		attrs = append(attrs, attribute.String("db.statement", strings.Join(stmts, "\n")))
+	}
+	ctx, _ = h.tracer.Start(ctx, "pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, nil
+}
+
+func (h *hook) AfterProcessPipeline(ctx context.Context, cmds []*ssdb.Cmd) error {
+	span := trace.SpanFromContext(ctx)
+	for _, cmd := range cmds {
+		if failed(cmd) {
+			end(span, cmd)
+			return nil
+		}
+	}
+	if len(cmds) > 0 {
+		end(span, cmds[0])
+	} else {
+		span.End()
+	}
+	return nil
+}
+
+// statement returns the command and its arguments, redacted.
+func (h *hook) statement(cmd *ssdb.Cmd) string {
+	args := cmd.Args
+	if strings.EqualFold(cmd.Name, "auth") {
+		args = RedactArgs(0)(cmd.Name, args)
+	} else if h.cfg.redact != nil {
+		args = h.cfg.redact(cmd.Name, args)
+	}
+	var b strings.Builder
+	b.WriteString(cmd.Name)
+	for _, arg := range args {
+		b.WriteByte(' ')
+		if v, ok := arg.([]byte); ok {
+			b.Write(v)
+		} else {
+			fmt.Fprint(&b, arg)
+		}
+	}
+	return b.String()
+}
+
+// end records the status of cmd and ends span.
+func end(span trace.Span, cmd *ssdb.Cmd) {
+	if cmd.Status != "" {
+		span.SetAttributes(attribute.String("db.response.status_code", cmd.Status))
+	}
+	if failed(cmd) {
+		if cmd.Err != nil {
+			span.RecordError(cmd.Err)
+			span.SetStatus(codes.Error, cmd.Err.Error())
+		} else {
+			span.SetStatus(codes.Error, cmd.Status)
+		}
+	}
+	span.End()
+}
+
+// failed reports whether cmd failed, a not_found response is not a failure.
+func failed(cmd *ssdb.Cmd) bool {
+	if cmd.Status == ssdb.StatusNotFound {
+		return false
+	}
+	return cmd.Err != nil || (cmd.Status != "" && cmd.Status != ssdb.StatusOK)
+}
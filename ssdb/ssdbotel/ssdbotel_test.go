@@ -0,0 +1,205 @@
+package ssdbotel_test
+
+import (
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbotel"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setup returns a fake server and an exporter receiving the spans of the
+// hooks created with the returned options.
+func setup(t *testing.T) (*ssdbtest.Server, *tracetest.InMemoryExporter, []ssdbotel.Option) {
+	t.Helper()
+	srv, err := ssdbtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { tp.Shutdown(t.Context()) })
+	return srv, exp, []ssdbotel.Option{ssdbotel.WithTracerProvider(tp), ssdbotel.WithPeer(srv.Host(), srv.Port())}
+}
+
+func dial(t *testing.T, srv *ssdbtest.Server, opts ...ssdb.Option) *ssdb.Client {
+	t.Helper()
+	c, err := ssdb.Dial(srv.Addr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// attr returns the value of the attribute key of span.
+func attr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// find returns the spans named name.
+func find(exp *tracetest.InMemoryExporter, name string) []tracetest.SpanStub {
+	var spans []tracetest.SpanStub
+	for _, span := range exp.GetSpans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestSpan(t *testing.T) {
+	srv, exp, opts := setup(t)
+	c := dial(t, srv, ssdb.WithHooks(ssdbotel.NewHook(opts...)))
+	if err := c.Set("a", "secret value"); err != nil {
+		t.Fatal(err)
+	}
+	spans := find(exp, "set")
+	if len(spans) != 1 {
+		t.Fatalf("set spans = %d, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.SpanKind != trace.SpanKindClient {
+		t.Errorf("kind = %v, want client", span.SpanKind)
+	}
+	want := map[attribute.Key]string{
+		"db.system":               "ssdb",
+		"db.operation":            "set",
+		"db.statement":            "set a ?",
+		"server.address":          srv.Host(),
+		"db.response.status_code": ssdb.StatusOK,
+	}
+	for key, val := range want {
+		if got := attr(span, key).AsString(); got != val {
+			t.Errorf("%s = %q, want %q", key, got, val)
+		}
+	}
+	if got := attr(span, "server.port").AsInt64(); got != int64(srv.Port()) {
+		t.Errorf("server.port = %d, want %d", got, srv.Port())
+	}
+	if span.Status.Code == codes.Error {
+		t.Errorf("status = %v", span.Status)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	srv, exp, opts := setup(t)
+	opts = append(opts, ssdbotel.WithRedactor(ssdbotel.RedactArgs(1)))
+	c := dial(t, srv, ssdb.WithHooks(ssdbotel.NewHook(opts...)))
+	if err := c.HashSet("h", "f", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got := attr(find(exp, "hset")[0], "db.statement").AsString(); got != "hset h ? ?" {
+		t.Errorf("db.statement = %q, want %q", got, "hset h ? ?")
+	}
+}
+
+func TestRedactAuth(t *testing.T) {
+	srv, exp, opts := setup(t)
+	srv.SetPassword("hunter2")
+	opts = append(opts, ssdbotel.WithRedactor(nil))
+	c := dial(t, srv, ssdb.WithPassword("hunter2"), ssdb.WithHooks(ssdbotel.NewHook(opts...)))
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	spans := find(exp, "auth")
+	if len(spans) != 1 {
+		t.Fatalf("auth spans = %d, want 1", len(spans))
+	}
+	if got := attr(spans[0], "db.statement").AsString(); got != "auth ?" {
+		t.Errorf("auth statement = %q, want %q", got, "auth ?")
+	}
+	if got := attr(find(exp, "set")[0], "db.statement").AsString(); got != "set a 1" {
+		t.Errorf("set statement without redactor = %q, want %q", got, "set a 1")
+	}
+}
+
+func TestRedactValues(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []interface{}
+		want []interface{}
+	}{
+		{"get", []interface{}{"k"}, []interface{}{"k"}},
+		{"hset", []interface{}{"h", "f", "v"}, []interface{}{"h", "?", "?"}},
+		{"multi_set", []interface{}{"a", "1", "b", "2"}, []interface{}{"a", "?", "b", "?"}},
+		{"multi_get", []interface{}{"a", "b"}, []interface{}{"a", "b"}},
+		{"scan", []interface{}{"a", "z", "10"}, []interface{}{"a", "z", "?"}},
+		{"unknown", []interface{}{"x"}, []interface{}{"?"}},
+	}
+	for _, tt := range tests {
+		got := ssdbotel.RedactValues(tt.cmd, tt.args)
+		if len(got) != len(tt.want) {
+			t.Errorf("RedactValues(%s, %v) = %v, want %v", tt.cmd, tt.args, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("RedactValues(%s, %v) = %v, want %v", tt.cmd, tt.args, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	srv, exp, opts := setup(t)
+	srv.AddFault("incr", ssdbtest.Fault{Response: []string{"error", "server busy"}})
+	c := dial(t, srv, ssdb.WithHooks(ssdbotel.NewHook(opts...)))
+	c.Get("missing")
+	c.Incr("a", 1)
+
+	get := find(exp, "get")[0]
+	if get.Status.Code == codes.Error {
+		t.Errorf("not_found span status = %v, want unset", get.Status)
+	}
+	if got := attr(get, "db.response.status_code").AsString(); got != ssdb.StatusNotFound {
+		t.Errorf("not_found status code = %q", got)
+	}
+	incr := find(exp, "incr")[0]
+	if incr.Status.Code != codes.Error {
+		t.Errorf("error span status = %v, want error", incr.Status)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	srv, exp, opts := setup(t)
+	c := dial(t, srv, ssdb.WithHooks(ssdbotel.NewHook(opts...)))
+	p := c.Pipeline()
+	p.Append("set", "a", "1")
+	p.Append("get", "a")
+	if _, err := p.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	spans := find(exp, "pipeline")
+	if len(spans) != 1 {
+		t.Fatalf("pipeline spans = %d, want 1", len(spans))
+	}
+	if got := attr(spans[0], "db.ssdb.num_cmd").AsInt64(); got != 2 {
+		t.Errorf("db.ssdb.num_cmd = %d, want 2", got)
+	}
+	if got := attr(spans[0], "db.statement").AsString(); got != "set a ?\nget a" {
+		t.Errorf("db.statement = %q", got)
+	}
+}
+
+func TestWithoutStatement(t *testing.T) {
+	srv, exp, opts := setup(t)
+	opts = append(opts, ssdbotel.WithDBStatement(false))
+	c := dial(t, srv, ssdb.WithHooks(ssdbotel.NewHook(opts...)))
+	c.Set("a", "1")
+	if got := attr(find(exp, "set")[0], "db.statement"); got.Type() != attribute.INVALID {
+		t.Errorf("db.statement = %q, want none", got.Emit())
+	}
+}
@@ -0,0 +1,137 @@
+// Package ssdbprom exports the metrics of ssdb clients to Prometheus.
+//
+//	m := ssdbprom.New(ssdbprom.Options{})
+//	prometheus.MustRegister(m)
+//	db, err := ssdb.Dial("127.0.0.1:8888", ssdb.WithMetrics(m))
+//
+// The zip compression ratio is
+// rate(ssdb_zip_compressed_bytes_total[5m]) / rate(ssdb_zip_raw_bytes_total[5m]).
+package ssdbprom
+
+import (
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options configures the metrics created by New.
+type Options struct {
+	// Namespace prefixes the metric names, "ssdb" by default.
+	Namespace string
+	// ConstLabels are added to every metric, e.g. the name of the server.
+	ConstLabels prometheus.Labels
+	// Buckets are the upper bounds of the latency histogram in seconds,
+	// prometheus.DefBuckets by default.
+	Buckets []float64
+}
+
+// Metrics is a ssdb.Metrics and a prometheus.Collector. One Metrics can be
+// shared by several clients, register it once.
+type Metrics struct {
+	commands      *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+	timeouts      prometheus.Counter
+	reconnects    *prometheus.CounterVec
+	bytesSent     prometheus.Counter
+	bytesReceived prometheus.Counter
+	zipRaw        prometheus.Counter
+	zipCompressed prometheus.Counter
+	latency       *prometheus.HistogramVec
+}
+
+var _ ssdb.Metrics = (*Metrics)(nil)
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// New creates the metrics, they are exported once registered.
+func New(opts Options) *Metrics {
+	ns := opts.Namespace
+	if ns == "" {
+		ns = "ssdb"
+	}
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: ns, Name: name, Help: help, ConstLabels: opts.ConstLabels,
+		})
+	}
+	counterVec := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: name, Help: help, ConstLabels: opts.ConstLabels,
+		}, labels)
+	}
+	return &Metrics{
+		commands:      counterVec("commands_total", "Commands completed, by command and response status.", "cmd", "status"),
+		errors:        counterVec("errors_total", "Commands with a status other than ok, by status.", "status"),
+		timeouts:      counter("timeouts_total", "Commands that timed out."),
+		reconnects:    counterVec("reconnects_total", "Reconnect attempts, by result.", "result"),
+		bytesSent:     counter("sent_bytes_total", "Bytes written to the connections."),
+		bytesReceived: counter("received_bytes_total", "Bytes read from the connections."),
+		zipRaw:        counter("zip_raw_bytes_total", "Size of the zipped packets before compression."),
+		zipCompressed: counter("zip_compressed_bytes_total", "Size of the zipped packets after compression."),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   ns,
+			Name:        "command_duration_seconds",
+			Help:        "Latency of the commands, by command.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     buckets,
+		}, []string{"cmd"}),
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.commands, m.errors, m.timeouts, m.reconnects, m.bytesSent,
+		m.bytesReceived, m.zipRaw, m.zipCompressed, m.latency,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) ObserveCommand(cmd string, status string, d time.Duration) {
+	m.commands.WithLabelValues(cmd, status).Inc()
+	if status != ssdb.StatusOK {
+		m.errors.WithLabelValues(status).Inc()
+	}
+	if status == ssdb.StatusTimeout {
+		m.timeouts.Inc()
+	}
+	m.latency.WithLabelValues(cmd).Observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveReconnect(ev ssdb.ReconnectEvent) {
+	switch {
+	case ev.State == ssdb.StateConnected:
+		m.reconnects.WithLabelValues("connected").Inc()
+	case ev.Err != nil:
+		m.reconnects.WithLabelValues("failed").Inc()
+	}
+}
+
+func (m *Metrics) ObserveBytesSent(n int) {
+	m.bytesSent.Add(float64(n))
+}
+
+func (m *Metrics) ObserveBytesReceived(n int) {
+	m.bytesReceived.Add(float64(n))
+}
+
+func (m *Metrics) ObserveZip(raw, zipped int) {
+	m.zipRaw.Add(float64(raw))
+	m.zipCompressed.Add(float64(zipped))
+}
@@ -0,0 +1,144 @@
+package ssdbprom_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbprom"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCounters(t *testing.T) {
+	m := ssdbprom.New(ssdbprom.Options{ConstLabels: prometheus.Labels{"server": "a"}})
+	m.ObserveCommand("get", ssdb.StatusOK, time.Millisecond)
+	m.ObserveCommand("get", ssdb.StatusOK, time.Millisecond)
+	m.ObserveCommand("get", ssdb.StatusNotFound, time.Millisecond)
+	m.ObserveCommand("set", ssdb.StatusTimeout, time.Second)
+	m.ObserveReconnect(ssdb.ReconnectEvent{State: ssdb.StateReconnecting, Err: errors.New("refused")})
+	m.ObserveReconnect(ssdb.ReconnectEvent{State: ssdb.StateConnected})
+	m.ObserveBytesSent(10)
+	m.ObserveBytesReceived(20)
+	m.ObserveZip(100, 25)
+
+	want := `
+# HELP ssdb_commands_total Commands completed, by command and response status.
+# TYPE ssdb_commands_total counter
+ssdb_commands_total{cmd="get",server="a",status="not_found"} 1
+ssdb_commands_total{cmd="get",server="a",status="ok"} 2
+ssdb_commands_total{cmd="set",server="a",status="timeout"} 1
+# HELP ssdb_errors_total Commands with a status other than ok, by status.
+# TYPE ssdb_errors_total counter
+ssdb_errors_total{server="a",status="not_found"} 1
+ssdb_errors_total{server="a",status="timeout"} 1
+# HELP ssdb_timeouts_total Commands that timed out.
+# TYPE ssdb_timeouts_total counter
+ssdb_timeouts_total{server="a"} 1
+# HELP ssdb_reconnects_total Reconnect attempts, by result.
+# TYPE ssdb_reconnects_total counter
+ssdb_reconnects_total{result="connected",server="a"} 1
+ssdb_reconnects_total{result="failed",server="a"} 1
+# HELP ssdb_sent_bytes_total Bytes written to the connections.
+# TYPE ssdb_sent_bytes_total counter
+ssdb_sent_bytes_total{server="a"} 10
+# HELP ssdb_received_bytes_total Bytes read from the connections.
+# TYPE ssdb_received_bytes_total counter
+ssdb_received_bytes_total{server="a"} 20
+# HELP ssdb_zip_raw_bytes_total Size of the zipped packets before compression.
+# TYPE ssdb_zip_raw_bytes_total counter
+ssdb_zip_raw_bytes_total{server="a"} 100
+# HELP ssdb_zip_compressed_bytes_total Size of the zipped packets after compression.
+# TYPE ssdb_zip_compressed_bytes_total counter
+ssdb_zip_compressed_bytes_total{server="a"} 25
+`
+	names := []string{
+		"ssdb_commands_total", "ssdb_errors_total", "ssdb_timeouts_total", "ssdb_reconnects_total",
+		"ssdb_sent_bytes_total", "ssdb_received_bytes_total", "ssdb_zip_raw_bytes_total",
+		"ssdb_zip_compressed_bytes_total",
+	}
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), names...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	m := ssdbprom.New(ssdbprom.Options{Namespace: "kv", Buckets: []float64{0.001, 0.01}})
+	m.ObserveCommand("get", ssdb.StatusOK, 500*time.Microsecond)
+	m.ObserveCommand("get", ssdb.StatusOK, 5*time.Millisecond)
+	m.ObserveCommand("get", ssdb.StatusOK, time.Second)
+	m.ObserveCommand("set", ssdb.StatusOK, 500*time.Microsecond)
+
+	want := `
+# HELP kv_command_duration_seconds Latency of the commands, by command.
+# TYPE kv_command_duration_seconds histogram
+kv_command_duration_seconds_bucket{cmd="get",le="0.001"} 1
+kv_command_duration_seconds_bucket{cmd="get",le="0.01"} 2
+kv_command_duration_seconds_bucket{cmd="get",le="+Inf"} 3
+kv_command_duration_seconds_sum{cmd="get"} 1.0055
+kv_command_duration_seconds_count{cmd="get"} 3
+kv_command_duration_seconds_bucket{cmd="set",le="0.001"} 1
+kv_command_duration_seconds_bucket{cmd="set",le="0.01"} 1
+kv_command_duration_seconds_bucket{cmd="set",le="+Inf"} 1
+kv_command_duration_seconds_sum{cmd="set"} 0.0005
+kv_command_duration_seconds_count{cmd="set"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "kv_command_duration_seconds"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	srv, err := ssdbtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	m := ssdbprom.New(ssdbprom.Options{})
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(m)
+	c, err := ssdb.Dial(srv.Addr, ssdb.WithMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Set("a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	c.Get("missing")
+
+	want := `
+# HELP ssdb_commands_total Commands completed, by command and response status.
+# TYPE ssdb_commands_total counter
+ssdb_commands_total{cmd="get",status="not_found"} 1
+ssdb_commands_total{cmd="set",status="ok"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "ssdb_commands_total"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(m, "ssdb_command_duration_seconds"); n != 2 {
+		t.Fatalf("%d latency histograms, want one per command", n)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "ssdb_sent_bytes_total", "ssdb_received_bytes_total":
+			if v := mf.GetMetric()[0].GetCounter().GetValue(); v == 0 {
+				t.Errorf("%s = 0", mf.GetName())
+			}
+		}
+	}
+	problems, err := testutil.GatherAndLint(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		t.Errorf("lint: %s: %s", p.Metric, p.Text)
+	}
+}
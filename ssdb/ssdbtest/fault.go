@@ -0,0 +1,136 @@
+package ssdbtest
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+// Fault changes how the server answers a command. The command is run before
+// the fault applies, a write hit by a fault is not undone.
+type Fault struct {
+	// Latency delays the response.
+	Latency time.Duration
+	// Response is sent instead of the response of the command, e.g.
+	// []string{"error", "server busy"}.
+	Response []string
+	// Corrupt replaces the first length header of the response with a
+	// malformed one.
+	Corrupt bool
+	// Disconnect closes the connection after DisconnectAfter bytes of the
+	// response, the connection is closed without an answer when
+	// DisconnectAfter is 0.
+	Disconnect      bool
+	DisconnectAfter int
+	// WriteChunk splits the response in writes of WriteChunk bytes with
+	// ChunkDelay between them.
+	WriteChunk int
+	ChunkDelay time.Duration
+	// Times is the number of commands the fault applies to, 0 means every
+	// command.
+	Times int
+}
+
+type fault struct {
+	cmd  string
+	f    Fault
+	left int
+}
+
+// AddFault injects f into the responses of the command cmd, an empty cmd
+// matches every command. Faults apply in the order they were added, a fault
+// used Times times is dropped.
+func (s *Server) AddFault(cmd string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{cmd: strings.ToLower(cmd), f: f, left: f.Times})
+}
+
+// ClearFaults removes all faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// CloseConnections drops all client connections, the server keeps accepting
+// new ones.
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		c.Close()
+	}
+}
+
+// fault returns the fault for the command name, nil when there is none.
+func (s *Server) fault(name string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ft := range s.faults {
+		if ft.cmd != "" && ft.cmd != name {
+			continue
+		}
+		f := ft.f
+		if ft.left > 0 {
+			ft.left--
+			if ft.left == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return &f
+	}
+	return nil
+}
+
+// inject writes resp to c as altered by f, it returns false when the
+// connection is to be closed.
+func inject(c net.Conn, w *proto.Writer, f *Fault, resp []string, zipped bool) bool {
+	if err := w.Flush(); err != nil {
+		return false
+	}
+	if f.Latency > 0 {
+		time.Sleep(f.Latency)
+	}
+	if f.Response != nil {
+		resp = f.Response
+	}
+	blocks := make([]interface{}, len(resp))
+	for i, b := range resp {
+		blocks[i] = b
+	}
+	var out []byte
+	if zipped {
+		out, _ = proto.AppendZipCommand(nil, blocks...)
+	} else {
+		out, _ = proto.AppendCommand(nil, blocks...)
+	}
+	if f.Corrupt {
+		if i := bytes.IndexByte(out, '\n'); i >= 0 {
+			out = append([]byte("x"), out[i:]...)
+		}
+	}
+	if f.Disconnect {
+		if f.DisconnectAfter < len(out) {
+			out = out[:f.DisconnectAfter]
+		}
+	}
+	chunk := f.WriteChunk
+	if chunk <= 0 {
+		chunk = len(out)
+	}
+	for len(out) > 0 {
+		n := min(chunk, len(out))
+		if _, err := c.Write(out[:n]); err != nil {
+			return false
+		}
+		out = out[n:]
+		if len(out) > 0 && f.ChunkDelay > 0 {
+			time.Sleep(f.ChunkDelay)
+		}
+	}
+	return !f.Disconnect
+}
@@ -0,0 +1,187 @@
+package ssdbtest
+
+func init() {
+	commands["hset"] = command{3, cmdHSet}
+	commands["hget"] = command{2, cmdHGet}
+	commands["hdel"] = command{2, cmdHDel}
+	commands["hincr"] = command{2, cmdHIncr}
+	commands["hexists"] = command{2, cmdHExists}
+	commands["hsize"] = command{1, cmdHSize}
+	commands["hlist"] = command{3, cmdHList}
+	commands["hrlist"] = command{3, cmdHRList}
+	commands["hkeys"] = command{4, cmdHKeys}
+	commands["hrkeys"] = command{4, cmdHRKeys}
+	commands["hgetall"] = command{1, cmdHGetAll}
+	commands["hscan"] = command{4, cmdHScan}
+	commands["hrscan"] = command{4, cmdHRScan}
+	commands["hclear"] = command{1, cmdHClear}
+	commands["multi_hset"] = command{3, cmdMultiHSet}
+	commands["multi_hget"] = command{2, cmdMultiHGet}
+	commands["multi_hdel"] = command{2, cmdMultiHDel}
+}
+
+// hash returns the hash name, creating it when create is set.
+func (s *Server) hash(name string, create bool) map[string]string {
+	h := s.hashes[name]
+	if h == nil && create {
+		h = make(map[string]string)
+		s.hashes[name] = h
+	}
+	return h
+}
+
+// hdel removes key from the hash name and drops the hash once empty.
+func (s *Server) hdel(name, key string) bool {
+	h := s.hashes[name]
+	if _, found := h[key]; !found {
+		return false
+	}
+	delete(h, key)
+	if len(h) == 0 {
+		delete(s.hashes, name)
+	}
+	return true
+}
+
+func cmdHSet(s *Server, args []string) []string {
+	h := s.hash(args[0], true)
+	_, found := h[args[1]]
+	h[args[1]] = args[2]
+	return boolResp(!found)
+}
+
+func cmdHGet(s *Server, args []string) []string {
+	val, found := s.hash(args[0], false)[args[1]]
+	if !found {
+		return notFound()
+	}
+	return ok(val)
+}
+
+func cmdHDel(s *Server, args []string) []string {
+	return boolResp(s.hdel(args[0], args[1]))
+}
+
+func cmdHIncr(s *Server, args []string) []string {
+	by := int64(1)
+	if len(args) > 2 {
+		var valid bool
+		if by, valid = parseInt(args[2]); !valid {
+			return errorResp("value is not an integer or out of range")
+		}
+	}
+	h := s.hash(args[0], true)
+	n := int64(0)
+	if val, found := h[args[1]]; found {
+		var valid bool
+		if n, valid = parseInt(val); !valid {
+			return errorResp("value is not an integer or out of range")
+		}
+	}
+	n += by
+	h[args[1]] = itoa(n)
+	return ok(itoa(n))
+}
+
+func cmdHExists(s *Server, args []string) []string {
+	_, found := s.hash(args[0], false)[args[1]]
+	return boolResp(found)
+}
+
+func cmdHSize(s *Server, args []string) []string {
+	return ok(itoa(int64(len(s.hash(args[0], false)))))
+}
+
+func (s *Server) listHashes(args []string, reverse bool) []string {
+	limit, valid := parseLimit(args[2])
+	if !valid {
+		return clientError("invalid limit")
+	}
+	return ok(scanKeys(s.hashes, args[0], args[1], limit, reverse)...)
+}
+
+func cmdHList(s *Server, args []string) []string {
+	return s.listHashes(args, false)
+}
+
+func cmdHRList(s *Server, args []string) []string {
+	return s.listHashes(args, true)
+}
+
+func (s *Server) scanHash(args []string, reverse bool, values bool) []string {
+	limit, valid := parseLimit(args[3])
+	if !valid {
+		return clientError("invalid limit")
+	}
+	h := s.hash(args[0], false)
+	resp := ok()
+	for _, key := range scanKeys(h, args[1], args[2], limit, reverse) {
+		resp = append(resp, key)
+		if values {
+			resp = append(resp, h[key])
+		}
+	}
+	return resp
+}
+
+func cmdHKeys(s *Server, args []string) []string {
+	return s.scanHash(args, false, false)
+}
+
+func cmdHRKeys(s *Server, args []string) []string {
+	return s.scanHash(args, true, false)
+}
+
+func cmdHScan(s *Server, args []string) []string {
+	return s.scanHash(args, false, true)
+}
+
+func cmdHRScan(s *Server, args []string) []string {
+	return s.scanHash(args, true, true)
+}
+
+func cmdHGetAll(s *Server, args []string) []string {
+	return s.scanHash([]string{args[0], "", "", "-1"}, false, true)
+}
+
+func cmdHClear(s *Server, args []string) []string {
+	n := len(s.hash(args[0], false))
+	delete(s.hashes, args[0])
+	return ok(itoa(int64(n)))
+}
+
+func cmdMultiHSet(s *Server, args []string) []string {
+	if len(args)%2 != 1 {
+		return clientError("wrong number of arguments")
+	}
+	h := s.hash(args[0], true)
+	n := int64(0)
+	for i := 1; i < len(args); i += 2 {
+		if _, found := h[args[i]]; !found {
+			n++
+		}
+		h[args[i]] = args[i+1]
+	}
+	return ok(itoa(n))
+}
+
+func cmdMultiHGet(s *Server, args []string) []string {
+	h := s.hash(args[0], false)
+	resp := ok()
+	for _, key := range args[1:] {
+		if val, found := h[key]; found {
+			resp = append(resp, key, val)
+		}
+	}
+	return resp
+}
+
+func cmdMultiHDel(s *Server, args []string) []string {
+	n := int64(0)
+	for _, key := range args[1:] {
+		if s.hdel(args[0], key) {
+			n++
+		}
+	}
+	return ok(itoa(n))
+}
@@ -0,0 +1,190 @@
+package ssdbtest
+
+import (
+	"strconv"
+	"time"
+)
+
+func init() {
+	commands["get"] = command{1, cmdGet}
+	commands["set"] = command{2, cmdSet}
+	commands["setx"] = command{3, cmdSetX}
+	commands["setnx"] = command{2, cmdSetNX}
+	commands["getset"] = command{2, cmdGetSet}
+	commands["del"] = command{1, cmdDel}
+	commands["incr"] = command{1, cmdIncr}
+	commands["exists"] = command{1, cmdExists}
+	commands["expire"] = command{2, cmdExpire}
+	commands["ttl"] = command{1, cmdTTL}
+	commands["scan"] = command{3, cmdScan}
+	commands["rscan"] = command{3, cmdRScan}
+	commands["keys"] = command{3, cmdKeys}
+	commands["rkeys"] = command{3, cmdRKeys}
+	commands["multi_set"] = command{2, cmdMultiSet}
+	commands["multi_get"] = command{1, cmdMultiGet}
+	commands["multi_del"] = command{1, cmdMultiDel}
+}
+
+func cmdGet(s *Server, args []string) []string {
+	val, found := s.kv[args[0]]
+	if !found {
+		return notFound()
+	}
+	return ok(val)
+}
+
+func (s *Server) set(key, val string) {
+	s.kv[key] = val
+	delete(s.expires, key)
+}
+
+func cmdSet(s *Server, args []string) []string {
+	s.set(args[0], args[1])
+	return ok("1")
+}
+
+func cmdSetX(s *Server, args []string) []string {
+	ttl, valid := parseInt(args[2])
+	if !valid {
+		return clientError("invalid ttl")
+	}
+	s.set(args[0], args[1])
+	s.expires[args[0]] = s.now().Add(time.Duration(ttl) * time.Second)
+	return ok("1")
+}
+
+func cmdSetNX(s *Server, args []string) []string {
+	if _, found := s.kv[args[0]]; found {
+		return ok("0")
+	}
+	s.set(args[0], args[1])
+	return ok("1")
+}
+
+func cmdGetSet(s *Server, args []string) []string {
+	old, found := s.kv[args[0]]
+	s.kv[args[0]] = args[1]
+	if !found {
+		return notFound()
+	}
+	return ok(old)
+}
+
+func cmdDel(s *Server, args []string) []string {
+	delete(s.kv, args[0])
+	delete(s.expires, args[0])
+	return ok("1")
+}
+
+func cmdIncr(s *Server, args []string) []string {
+	by := int64(1)
+	if len(args) > 1 {
+		var valid bool
+		if by, valid = parseInt(args[1]); !valid {
+			return errorResp("value is not an integer or out of range")
+		}
+	}
+	n := int64(0)
+	if val, found := s.kv[args[0]]; found {
+		var valid bool
+		if n, valid = parseInt(val); !valid {
+			return errorResp("value is not an integer or out of range")
+		}
+	}
+	n += by
+	s.kv[args[0]] = itoa(n)
+	return ok(itoa(n))
+}
+
+func cmdExists(s *Server, args []string) []string {
+	_, found := s.kv[args[0]]
+	return boolResp(found)
+}
+
+func cmdExpire(s *Server, args []string) []string {
+	ttl, valid := parseInt(args[1])
+	if !valid {
+		return clientError("invalid ttl")
+	}
+	if _, found := s.kv[args[0]]; !found {
+		return ok("0")
+	}
+	s.expires[args[0]] = s.now().Add(time.Duration(ttl) * time.Second)
+	return ok("1")
+}
+
+func cmdTTL(s *Server, args []string) []string {
+	at, found := s.expires[args[0]]
+	if !found {
+		return ok("-1")
+	}
+	return ok(itoa(int64((at.Sub(s.now()) + time.Second - 1) / time.Second)))
+}
+
+func (s *Server) scanKV(args []string, reverse bool, values bool) []string {
+	limit, valid := parseLimit(args[2])
+	if !valid {
+		return clientError("invalid limit")
+	}
+	resp := ok()
+	for _, key := range scanKeys(s.kv, args[0], args[1], limit, reverse) {
+		resp = append(resp, key)
+		if values {
+			resp = append(resp, s.kv[key])
+		}
+	}
+	return resp
+}
+
+func cmdScan(s *Server, args []string) []string {
+	return s.scanKV(args, false, true)
+}
+
+func cmdRScan(s *Server, args []string) []string {
+	return s.scanKV(args, true, true)
+}
+
+func cmdKeys(s *Server, args []string) []string {
+	return s.scanKV(args, false, false)
+}
+
+func cmdRKeys(s *Server, args []string) []string {
+	return s.scanKV(args, true, false)
+}
+
+func cmdMultiSet(s *Server, args []string) []string {
+	if len(args)%2 != 0 {
+		return clientError("wrong number of arguments")
+	}
+	for i := 0; i < len(args); i += 2 {
+		s.set(args[i], args[i+1])
+	}
+	return ok(itoa(int64(len(args) / 2)))
+}
+
+func cmdMultiGet(s *Server, args []string) []string {
+	resp := ok()
+	for _, key := range args {
+		if val, found := s.kv[key]; found {
+			resp = append(resp, key, val)
+		}
+	}
+	return resp
+}
+
+func cmdMultiDel(s *Server, args []string) []string {
+	n := int64(0)
+	for _, key := range args {
+		if _, found := s.kv[key]; found {
+			n++
+		}
+		delete(s.kv, key)
+		delete(s.expires, key)
+	}
+	return ok(itoa(n))
+}
+
+func parseInt(arg string) (int64, bool) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	return n, err == nil
+}
@@ -0,0 +1,233 @@
+package ssdbtest
+
+func init() {
+	commands["qpush_front"] = command{2, cmdQPushFront}
+	commands["qpush_back"] = command{2, cmdQPushBack}
+	commands["qpush"] = command{2, cmdQPushBack}
+	commands["qpop_front"] = command{1, cmdQPopFront}
+	commands["qpop_back"] = command{1, cmdQPopBack}
+	commands["qpop"] = command{1, cmdQPopFront}
+	commands["qfront"] = command{1, cmdQFront}
+	commands["qback"] = command{1, cmdQBack}
+	commands["qsize"] = command{1, cmdQSize}
+	commands["qget"] = command{2, cmdQGet}
+	commands["qset"] = command{3, cmdQSet}
+	commands["qrange"] = command{3, cmdQRange}
+	commands["qslice"] = command{3, cmdQSlice}
+	commands["qtrim_front"] = command{2, cmdQTrimFront}
+	commands["qtrim_back"] = command{2, cmdQTrimBack}
+	commands["qclear"] = command{1, cmdQClear}
+	commands["qlist"] = command{3, cmdQList}
+	commands["qrlist"] = command{3, cmdQRList}
+}
+
+// setQueue stores q as the queue name, an empty queue is removed.
+func (s *Server) setQueue(name string, q []string) {
+	if len(q) == 0 {
+		delete(s.queues, name)
+		return
+	}
+	s.queues[name] = q
+}
+
+// index converts an index counting from the back when negative, it returns
+// false when the index is out of the queue.
+func index(q []string, arg string) (int, bool) {
+	i, valid := parseInt(arg)
+	if !valid {
+		return 0, false
+	}
+	if i < 0 {
+		i += int64(len(q))
+	}
+	if i < 0 || i >= int64(len(q)) {
+		return 0, false
+	}
+	return int(i), true
+}
+
+func cmdQPushFront(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	items := make([]string, 0, len(q)+len(args)-1)
+	for i := len(args) - 1; i > 0; i-- {
+		items = append(items, args[i])
+	}
+	q = append(items, q...)
+	s.setQueue(args[0], q)
+	return ok(itoa(int64(len(q))))
+}
+
+func cmdQPushBack(s *Server, args []string) []string {
+	q := append(s.queues[args[0]], args[1:]...)
+	s.setQueue(args[0], q)
+	return ok(itoa(int64(len(q))))
+}
+
+func (s *Server) qpop(args []string, back bool) []string {
+	size := 1
+	if len(args) > 1 {
+		var valid bool
+		if size, valid = parseLimit(args[1]); !valid || size < 0 {
+			return clientError("invalid size")
+		}
+	}
+	q := s.queues[args[0]]
+	if size > len(q) {
+		size = len(q)
+	}
+	var items []string
+	if back {
+		for i := len(q) - 1; i >= len(q)-size; i-- {
+			items = append(items, q[i])
+		}
+		q = q[:len(q)-size]
+	} else {
+		items = append(items, q[:size]...)
+		q = q[size:]
+	}
+	s.setQueue(args[0], q)
+	return ok(items...)
+}
+
+func cmdQPopFront(s *Server, args []string) []string {
+	return s.qpop(args, false)
+}
+
+func cmdQPopBack(s *Server, args []string) []string {
+	return s.qpop(args, true)
+}
+
+func cmdQFront(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	if len(q) == 0 {
+		return notFound()
+	}
+	return ok(q[0])
+}
+
+func cmdQBack(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	if len(q) == 0 {
+		return notFound()
+	}
+	return ok(q[len(q)-1])
+}
+
+func cmdQSize(s *Server, args []string) []string {
+	return ok(itoa(int64(len(s.queues[args[0]]))))
+}
+
+func cmdQGet(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	i, found := index(q, args[1])
+	if !found {
+		return notFound()
+	}
+	return ok(q[i])
+}
+
+func cmdQSet(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	i, found := index(q, args[1])
+	if !found {
+		return errorResp("index out of range")
+	}
+	q[i] = args[2]
+	return ok("1")
+}
+
+func cmdQRange(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	offset, valid := parseLimit(args[1])
+	limit, valid2 := parseLimit(args[2])
+	if !valid || !valid2 {
+		return clientError("invalid offset or limit")
+	}
+	if offset < 0 {
+		offset += len(q)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(q) {
+		offset = len(q)
+	}
+	items := q[offset:]
+	if limit >= 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return ok(items...)
+}
+
+func cmdQSlice(s *Server, args []string) []string {
+	q := s.queues[args[0]]
+	begin, valid := parseLimit(args[1])
+	end, valid2 := parseLimit(args[2])
+	if !valid || !valid2 {
+		return clientError("invalid begin or end")
+	}
+	if begin < 0 {
+		begin += len(q)
+	}
+	if end < 0 {
+		end += len(q)
+	}
+	if begin < 0 {
+		begin = 0
+	}
+	if end >= len(q) {
+		end = len(q) - 1
+	}
+	if begin > end {
+		return ok()
+	}
+	return ok(q[begin : end+1]...)
+}
+
+func (s *Server) qtrim(args []string, back bool) []string {
+	size, valid := parseLimit(args[1])
+	if !valid || size < 0 {
+		return clientError("invalid size")
+	}
+	q := s.queues[args[0]]
+	if size > len(q) {
+		size = len(q)
+	}
+	if back {
+		q = q[:len(q)-size]
+	} else {
+		q = q[size:]
+	}
+	s.setQueue(args[0], q)
+	return ok(itoa(int64(size)))
+}
+
+func cmdQTrimFront(s *Server, args []string) []string {
+	return s.qtrim(args, false)
+}
+
+func cmdQTrimBack(s *Server, args []string) []string {
+	return s.qtrim(args, true)
+}
+
+func cmdQClear(s *Server, args []string) []string {
+	n := len(s.queues[args[0]])
+	delete(s.queues, args[0])
+	return ok(itoa(int64(n)))
+}
+
+func (s *Server) listQueues(args []string, reverse bool) []string {
+	limit, valid := parseLimit(args[2])
+	if !valid {
+		return clientError("invalid limit")
+	}
+	return ok(scanKeys(s.queues, args[0], args[1], limit, reverse)...)
+}
+
+func cmdQList(s *Server, args []string) []string {
+	return s.listQueues(args, false)
+}
+
+func cmdQRList(s *Server, args []string) []string {
+	return s.listQueues(args, true)
+}
@@ -0,0 +1,368 @@
+// Package ssdbtest provides an in-memory SSDB server for tests.
+//
+// The server speaks the SSDB protocol, zip envelope included, and implements
+// the KV, hash, sorted set and queue commands, key TTL and auth. Faults such
+// as latency, dropped connections or malformed responses are injected with
+// Server.AddFault:
+//
+//	srv, err := ssdbtest.NewServer()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer srv.Close()
+//	db, err := ssdb.Connect(srv.Host(), srv.Port(), "")
+package ssdbtest
+
+import (
+	"crypto/x509"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+// Server is an in-memory SSDB server. Its data is shared by all connections.
+type Server struct {
+	// Network and Addr are the address the server listens on, a socket path
+	// for the "unix" network.
+	Network string
+	Addr    string
+
+	ln       net.Listener
+	mu       sync.Mutex
+	password string
+	slaveOf  string
+	offset   time.Duration
+	kv       map[string]string
+	expires  map[string]time.Time
+	hashes   map[string]map[string]string
+	zsets    map[string]map[string]int64
+	queues   map[string][]string
+	conns    map[net.Conn]struct{}
+	faults   []*fault
+	closed   bool
+	wg       sync.WaitGroup
+
+	certificate *x509.Certificate
+}
+
+// NewServer starts a server on a loopback TCP port.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	return NewServerWithListener(ln), nil
+}
+
+// NewUnixServer starts a server on the unix socket path.
+func NewUnixServer(path string) (*Server, error) {
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewServerWithListener(ln), nil
+}
+
+// NewServerWithListener starts a server accepting connections from ln.
+func NewServerWithListener(ln net.Listener) *Server {
+	s := &Server{
+		Network: ln.Addr().Network(),
+		Addr:    ln.Addr().String(),
+		ln:      ln,
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.Flush()
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Host returns the host to pass to ssdb.Connect, the socket path for a unix
+// server.
+func (s *Server) Host() string {
+	if host, _, err := net.SplitHostPort(s.Addr); err == nil && s.Network != "unix" {
+		return host
+	}
+	return s.Addr
+}
+
+// Port returns the port to pass to ssdb.Connect, 0 for a unix server.
+func (s *Server) Port() int {
+	if s.Network == "unix" {
+		return 0
+	}
+	_, port, _ := net.SplitHostPort(s.Addr)
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// SetPassword makes the server require auth, an empty password disables it.
+// Connections already authenticated are not affected.
+func (s *Server) SetPassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// SetSlaveOf makes info report the server as a slave of the server at addr,
+// as an SSDB slave configured with type sync does. An empty addr makes it a
+// master again. The data is not replicated.
+func (s *Server) SetSlaveOf(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slaveOf = addr
+}
+
+// FastForward moves the clock of the server by d, keys whose TTL ends are
+// expired.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// Flush removes all data.
+func (s *Server) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+}
+
+func (s *Server) reset() {
+	s.kv = make(map[string]string)
+	s.expires = make(map[string]time.Time)
+	s.hashes = make(map[string]map[string]string)
+	s.zsets = make(map[string]map[string]int64)
+	s.queues = make(map[string][]string)
+}
+
+// Close stops the server and closes all connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			c.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+// session is the state of a connection.
+type session struct {
+	authed bool
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+	rd := proto.NewReader(c)
+	w := proto.NewWriter(c)
+	var sess session
+	for {
+		args, err := rd.ReadStrings()
+		if err != nil {
+			return
+		}
+		var resp []string
+		var f *Fault
+		// An empty request, e.g. a zip packet without blocks, gets a client
+		// error and no fault.
+		if len(args) == 0 {
+			resp = clientError("empty request")
+		} else {
+			resp = s.exec(&sess, args)
+			f = s.fault(strings.ToLower(args[0]))
+		}
+		if f != nil {
+			if !inject(c, w, f, resp, rd.Zipped()) {
+				return
+			}
+			continue
+		}
+		if rd.Zipped() {
+			blocks := make([]interface{}, len(resp))
+			for i, b := range resp {
+				blocks[i] = b
+			}
+			err = w.WriteZipCommand(blocks...)
+		} else {
+			err = w.WriteStrings(resp...)
+		}
+		if err != nil {
+			return
+		}
+		if rd.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// command is the handler of a command, args does not include the command
+// name and has at least min elements.
+type command struct {
+	min int
+	fn  func(s *Server, args []string) []string
+}
+
+var commands = map[string]command{}
+
+func (s *Server) exec(sess *session, args []string) []string {
+	name := strings.ToLower(args[0])
+	args = args[1:]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name == "auth" {
+		if len(args) < 1 {
+			return clientError("wrong number of arguments")
+		}
+		if s.password != "" && args[0] != s.password {
+			return []string{"error", "invalid password"}
+		}
+		sess.authed = true
+		return ok("1")
+	}
+	if s.password != "" && !sess.authed {
+		return []string{"noauth", "authentication required"}
+	}
+	cmd, found := commands[name]
+	if !found {
+		return clientError("Unknown Command: " + name)
+	}
+	if len(args) < cmd.min {
+		return clientError("wrong number of arguments")
+	}
+	s.expire()
+	return cmd.fn(s, args)
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// expire removes the keys whose TTL has ended.
+func (s *Server) expire() {
+	now := s.now()
+	for key, at := range s.expires {
+		if !now.Before(at) {
+			delete(s.kv, key)
+			delete(s.expires, key)
+		}
+	}
+}
+
+func init() {
+	commands["ping"] = command{0, func(s *Server, args []string) []string {
+		return ok()
+	}}
+	commands["info"] = command{0, func(s *Server, args []string) []string {
+		if s.slaveOf != "" {
+			return ok("ssdb-server", "version", "ssdbtest", "replication",
+				"slaveof "+s.slaveOf+"\n    type       : sync\n    status     : SYNC\n")
+		}
+		return ok("ssdb-server", "version", "ssdbtest")
+	}}
+	commands["dbsize"] = command{0, func(s *Server, args []string) []string {
+		return ok(itoa(int64(len(s.kv) + len(s.hashes) + len(s.zsets) + len(s.queues))))
+	}}
+	commands["flushdb"] = command{0, func(s *Server, args []string) []string {
+		s.reset()
+		return ok()
+	}}
+}
+
+func ok(vals ...string) []string {
+	return append([]string{"ok"}, vals...)
+}
+
+func notFound() []string {
+	return []string{"not_found"}
+}
+
+func clientError(msg string) []string {
+	return []string{"client_error", msg}
+}
+
+func errorResp(msg string) []string {
+	return []string{"error", msg}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func boolResp(b bool) []string {
+	if b {
+		return ok("1")
+	}
+	return ok("0")
+}
+
+// parseLimit parses the limit argument of the scan commands.
+func parseLimit(arg string) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	return n, err == nil
+}
+
+// inRange reports whether key is in (start,end], an empty bound is open.
+func inRange(key, start, end string) bool {
+	return (start == "" || key > start) && (end == "" || key <= end)
+}
+
+// inRRange reports whether key is in [end,start), an empty bound is open.
+func inRRange(key, start, end string) bool {
+	return (start == "" || key < start) && (end == "" || key >= end)
+}
+
+// scanKeys returns up to limit keys of m in (start,end] in ascending order,
+// or in [end,start) in descending order when reverse is set.
+func scanKeys[V any](m map[string]V, start, end string, limit int, reverse bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		if reverse && inRRange(key, start, end) || !reverse && inRange(key, start, end) {
+			keys = append(keys, key)
+		}
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	} else {
+		sort.Strings(keys)
+	}
+	if limit >= 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
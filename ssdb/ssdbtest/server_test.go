@@ -0,0 +1,37 @@
+package ssdbtest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb/proto"
+)
+
+func TestEmptyZipRequest(t *testing.T) {
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	srv.AddFault("get", Fault{Response: []string{"error", "fault"}})
+
+	c, err := net.Dial("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	packet, err := proto.AppendZipCommand(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Write(packet); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := proto.NewReader(c).ReadStrings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) == 0 || resp[0] != "client_error" {
+		t.Fatalf("response = %q, want a client_error", resp)
+	}
+}
@@ -0,0 +1,90 @@
+package ssdbtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+)
+
+// NewTLSServer starts a server on a loopback TCP port behind TLS. When config
+// has no certificate the server uses a self-signed one for 127.0.0.1, ::1
+// and localhost, see Server.Certificate and Server.ClientTLSConfig. Set
+// config.ClientAuth to require client certificates.
+func NewTLSServer(config *tls.Config) (*Server, error) {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	var leaf *x509.Certificate
+	if len(config.Certificates) == 0 && config.GetCertificate == nil {
+		cert, err := selfSigned()
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+		leaf = cert.Leaf
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		return nil, err
+	}
+	s := NewServerWithListener(ln)
+	s.certificate = leaf
+	return s, nil
+}
+
+// Certificate returns the self-signed certificate of a TLS server, nil when
+// the certificate came with the config.
+func (s *Server) Certificate() *x509.Certificate {
+	return s.certificate
+}
+
+// ClientTLSConfig returns a config for the clients of a TLS server that
+// trusts its self-signed certificate.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	config := &tls.Config{ServerName: s.Host()}
+	if s.certificate != nil {
+		config.RootCAs = x509.NewCertPool()
+		config.RootCAs.AddCert(s.certificate)
+	}
+	return config
+}
+
+func selfSigned() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"ssdbtest"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
@@ -0,0 +1,432 @@
+package ssdbtest
+
+import (
+	"sort"
+	"strconv"
+)
+
+func init() {
+	commands["zset"] = command{3, cmdZSet}
+	commands["zget"] = command{2, cmdZGet}
+	commands["zdel"] = command{2, cmdZDel}
+	commands["zincr"] = command{2, cmdZIncr}
+	commands["zexists"] = command{2, cmdZExists}
+	commands["zsize"] = command{1, cmdZSize}
+	commands["zlist"] = command{3, cmdZList}
+	commands["zrlist"] = command{3, cmdZRList}
+	commands["zrank"] = command{2, cmdZRank}
+	commands["zrrank"] = command{2, cmdZRRank}
+	commands["zrange"] = command{3, cmdZRange}
+	commands["zrrange"] = command{3, cmdZRRange}
+	commands["zscan"] = command{5, cmdZScan}
+	commands["zrscan"] = command{5, cmdZRScan}
+	commands["zkeys"] = command{5, cmdZKeys}
+	commands["zcount"] = command{3, cmdZCount}
+	commands["zsum"] = command{3, cmdZSum}
+	commands["zavg"] = command{3, cmdZAvg}
+	commands["zremrangebyrank"] = command{3, cmdZRemRangeByRank}
+	commands["zremrangebyscore"] = command{3, cmdZRemRangeByScore}
+	commands["zpop_front"] = command{2, cmdZPopFront}
+	commands["zpop_back"] = command{2, cmdZPopBack}
+	commands["zclear"] = command{1, cmdZClear}
+	commands["multi_zset"] = command{3, cmdMultiZSet}
+	commands["multi_zget"] = command{2, cmdMultiZGet}
+	commands["multi_zdel"] = command{2, cmdMultiZDel}
+}
+
+type member struct {
+	key   string
+	score int64
+}
+
+// members returns the members of the sorted set name in ascending order of
+// score, then key.
+func (s *Server) members(name string, reverse bool) []member {
+	z := s.zsets[name]
+	ms := make([]member, 0, len(z))
+	for key, score := range z {
+		ms = append(ms, member{key, score})
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].score != ms[j].score {
+			return ms[i].score < ms[j].score
+		}
+		return ms[i].key < ms[j].key
+	})
+	if reverse {
+		for i, j := 0, len(ms)-1; i < j; i, j = i+1, j-1 {
+			ms[i], ms[j] = ms[j], ms[i]
+		}
+	}
+	return ms
+}
+
+func (s *Server) zset(name string) map[string]int64 {
+	z := s.zsets[name]
+	if z == nil {
+		z = make(map[string]int64)
+		s.zsets[name] = z
+	}
+	return z
+}
+
+func (s *Server) zdel(name, key string) bool {
+	z := s.zsets[name]
+	if _, found := z[key]; !found {
+		return false
+	}
+	delete(z, key)
+	if len(z) == 0 {
+		delete(s.zsets, name)
+	}
+	return true
+}
+
+func membersResp(ms []member) []string {
+	resp := ok()
+	for _, m := range ms {
+		resp = append(resp, m.key, itoa(m.score))
+	}
+	return resp
+}
+
+// scoreRange parses an inclusive score range, an empty bound is open.
+type scoreRange struct {
+	min, max       int64
+	hasMin, hasMax bool
+}
+
+func parseScoreRange(start, end string) (scoreRange, bool) {
+	var r scoreRange
+	var valid bool
+	if start != "" {
+		if r.min, valid = parseInt(start); !valid {
+			return r, false
+		}
+		r.hasMin = true
+	}
+	if end != "" {
+		if r.max, valid = parseInt(end); !valid {
+			return r, false
+		}
+		r.hasMax = true
+	}
+	return r, true
+}
+
+func (r scoreRange) contains(score int64) bool {
+	return (!r.hasMin || score >= r.min) && (!r.hasMax || score <= r.max)
+}
+
+func cmdZSet(s *Server, args []string) []string {
+	score, valid := parseInt(args[2])
+	if !valid {
+		return clientError("invalid score")
+	}
+	z := s.zset(args[0])
+	_, found := z[args[1]]
+	z[args[1]] = score
+	return boolResp(!found)
+}
+
+func cmdZGet(s *Server, args []string) []string {
+	score, found := s.zsets[args[0]][args[1]]
+	if !found {
+		return notFound()
+	}
+	return ok(itoa(score))
+}
+
+func cmdZDel(s *Server, args []string) []string {
+	return boolResp(s.zdel(args[0], args[1]))
+}
+
+func cmdZIncr(s *Server, args []string) []string {
+	by := int64(1)
+	if len(args) > 2 {
+		var valid bool
+		if by, valid = parseInt(args[2]); !valid {
+			return clientError("invalid score")
+		}
+	}
+	z := s.zset(args[0])
+	z[args[1]] += by
+	return ok(itoa(z[args[1]]))
+}
+
+func cmdZExists(s *Server, args []string) []string {
+	_, found := s.zsets[args[0]][args[1]]
+	return boolResp(found)
+}
+
+func cmdZSize(s *Server, args []string) []string {
+	return ok(itoa(int64(len(s.zsets[args[0]]))))
+}
+
+func (s *Server) listZSets(args []string, reverse bool) []string {
+	limit, valid := parseLimit(args[2])
+	if !valid {
+		return clientError("invalid limit")
+	}
+	return ok(scanKeys(s.zsets, args[0], args[1], limit, reverse)...)
+}
+
+func cmdZList(s *Server, args []string) []string {
+	return s.listZSets(args, false)
+}
+
+func cmdZRList(s *Server, args []string) []string {
+	return s.listZSets(args, true)
+}
+
+func (s *Server) zrank(args []string, reverse bool) []string {
+	for i, m := range s.members(args[0], reverse) {
+		if m.key == args[1] {
+			return ok(itoa(int64(i)))
+		}
+	}
+	return notFound()
+}
+
+func cmdZRank(s *Server, args []string) []string {
+	return s.zrank(args, false)
+}
+
+func cmdZRRank(s *Server, args []string) []string {
+	return s.zrank(args, true)
+}
+
+func (s *Server) zrange(args []string, reverse bool) []string {
+	offset, valid := parseLimit(args[1])
+	limit, valid2 := parseLimit(args[2])
+	if !valid || !valid2 || offset < 0 {
+		return clientError("invalid offset or limit")
+	}
+	ms := s.members(args[0], reverse)
+	if offset > len(ms) {
+		offset = len(ms)
+	}
+	ms = ms[offset:]
+	if limit >= 0 && len(ms) > limit {
+		ms = ms[:limit]
+	}
+	return membersResp(ms)
+}
+
+func cmdZRange(s *Server, args []string) []string {
+	return s.zrange(args, false)
+}
+
+func cmdZRRange(s *Server, args []string) []string {
+	return s.zrange(args, true)
+}
+
+// zscan returns the members after (keyStart,scoreStart) with a score in
+// the range of scoreStart and scoreEnd.
+func (s *Server) zscan(args []string, reverse bool) ([]member, bool) {
+	keyStart, scoreStart, scoreEnd := args[1], args[2], args[3]
+	limit, valid := parseLimit(args[4])
+	if !valid {
+		return nil, false
+	}
+	var r scoreRange
+	if reverse {
+		r, valid = parseScoreRange(scoreEnd, scoreStart)
+	} else {
+		r, valid = parseScoreRange(scoreStart, scoreEnd)
+	}
+	if !valid {
+		return nil, false
+	}
+	start, _ := parseInt(scoreStart)
+	var ms []member
+	for _, m := range s.members(args[0], reverse) {
+		if !r.contains(m.score) {
+			continue
+		}
+		if keyStart != "" && scoreStart != "" && m.score == start {
+			if !reverse && m.key <= keyStart || reverse && m.key >= keyStart {
+				continue
+			}
+		}
+		if limit >= 0 && len(ms) == limit {
+			break
+		}
+		ms = append(ms, m)
+	}
+	return ms, true
+}
+
+func cmdZScan(s *Server, args []string) []string {
+	ms, valid := s.zscan(args, false)
+	if !valid {
+		return clientError("invalid score or limit")
+	}
+	return membersResp(ms)
+}
+
+func cmdZRScan(s *Server, args []string) []string {
+	ms, valid := s.zscan(args, true)
+	if !valid {
+		return clientError("invalid score or limit")
+	}
+	return membersResp(ms)
+}
+
+func cmdZKeys(s *Server, args []string) []string {
+	ms, valid := s.zscan(args, false)
+	if !valid {
+		return clientError("invalid score or limit")
+	}
+	resp := ok()
+	for _, m := range ms {
+		resp = append(resp, m.key)
+	}
+	return resp
+}
+
+// inScores returns the members of name with a score in [start,end].
+func (s *Server) inScores(args []string) ([]member, bool) {
+	r, valid := parseScoreRange(args[1], args[2])
+	if !valid {
+		return nil, false
+	}
+	var ms []member
+	for _, m := range s.members(args[0], false) {
+		if r.contains(m.score) {
+			ms = append(ms, m)
+		}
+	}
+	return ms, true
+}
+
+func cmdZCount(s *Server, args []string) []string {
+	ms, valid := s.inScores(args)
+	if !valid {
+		return clientError("invalid score")
+	}
+	return ok(itoa(int64(len(ms))))
+}
+
+func cmdZSum(s *Server, args []string) []string {
+	ms, valid := s.inScores(args)
+	if !valid {
+		return clientError("invalid score")
+	}
+	sum := int64(0)
+	for _, m := range ms {
+		sum += m.score
+	}
+	return ok(itoa(sum))
+}
+
+func cmdZAvg(s *Server, args []string) []string {
+	ms, valid := s.inScores(args)
+	if !valid {
+		return clientError("invalid score")
+	}
+	if len(ms) == 0 {
+		return ok("0")
+	}
+	sum := int64(0)
+	for _, m := range ms {
+		sum += m.score
+	}
+	return ok(strconv.FormatFloat(float64(sum)/float64(len(ms)), 'f', -1, 64))
+}
+
+func cmdZRemRangeByRank(s *Server, args []string) []string {
+	start, valid := parseLimit(args[1])
+	end, valid2 := parseLimit(args[2])
+	if !valid || !valid2 {
+		return clientError("invalid rank")
+	}
+	n := int64(0)
+	for i, m := range s.members(args[0], false) {
+		if i >= start && i <= end && s.zdel(args[0], m.key) {
+			n++
+		}
+	}
+	return ok(itoa(n))
+}
+
+func cmdZRemRangeByScore(s *Server, args []string) []string {
+	ms, valid := s.inScores(args)
+	if !valid {
+		return clientError("invalid score")
+	}
+	for _, m := range ms {
+		s.zdel(args[0], m.key)
+	}
+	return ok(itoa(int64(len(ms))))
+}
+
+func (s *Server) zpop(args []string, reverse bool) []string {
+	limit, valid := parseLimit(args[1])
+	if !valid {
+		return clientError("invalid limit")
+	}
+	ms := s.members(args[0], reverse)
+	if limit >= 0 && len(ms) > limit {
+		ms = ms[:limit]
+	}
+	for _, m := range ms {
+		s.zdel(args[0], m.key)
+	}
+	return membersResp(ms)
+}
+
+func cmdZPopFront(s *Server, args []string) []string {
+	return s.zpop(args, false)
+}
+
+func cmdZPopBack(s *Server, args []string) []string {
+	return s.zpop(args, true)
+}
+
+func cmdZClear(s *Server, args []string) []string {
+	n := len(s.zsets[args[0]])
+	delete(s.zsets, args[0])
+	return ok(itoa(int64(n)))
+}
+
+func cmdMultiZSet(s *Server, args []string) []string {
+	if len(args)%2 != 1 {
+		return clientError("wrong number of arguments")
+	}
+	for i := 2; i < len(args); i += 2 {
+		if _, valid := parseInt(args[i]); !valid {
+			return clientError("invalid score")
+		}
+	}
+	z := s.zset(args[0])
+	n := int64(0)
+	for i := 1; i < len(args); i += 2 {
+		if _, found := z[args[i]]; !found {
+			n++
+		}
+		z[args[i]], _ = parseInt(args[i+1])
+	}
+	return ok(itoa(n))
+}
+
+func cmdMultiZGet(s *Server, args []string) []string {
+	z := s.zsets[args[0]]
+	resp := ok()
+	for _, key := range args[1:] {
+		if score, found := z[key]; found {
+			resp = append(resp, key, itoa(score))
+		}
+	}
+	return resp
+}
+
+func cmdMultiZDel(s *Server, args []string) []string {
+	n := int64(0)
+	for _, key := range args[1:] {
+		if s.zdel(args[0], key) {
+			n++
+		}
+	}
+	return ok(itoa(n))
+}
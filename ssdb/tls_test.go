@@ -0,0 +1,124 @@
+package ssdb_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+func newTLSServer(t *testing.T, config *tls.Config) *ssdbtest.Server {
+	t.Helper()
+	srv, err := ssdbtest.NewTLSServer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func TestTLS(t *testing.T) {
+	srv := newTLSServer(t, nil)
+	testKV(t, dial(t, srv, ssdb.WithTLSConfig(srv.ClientTLSConfig())))
+}
+
+func TestTLSUntrusted(t *testing.T) {
+	srv := newTLSServer(t, nil)
+	c, err := ssdb.Dial(srv.Addr, ssdb.WithTLSConfig(&tls.Config{}), ssdb.WithDialTimeout(time.Second))
+	if err == nil {
+		c.Close()
+		t.Fatal("Dial trusted an unknown certificate")
+	}
+}
+
+func TestTLSServerName(t *testing.T) {
+	srv := newTLSServer(t, nil)
+	config := srv.ClientTLSConfig()
+	config.ServerName = "other.example"
+	c, err := ssdb.Dial(srv.Addr, ssdb.WithTLSConfig(config))
+	if err == nil {
+		c.Close()
+		t.Fatal("Dial accepted a certificate for another name")
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	// The server trusts the certificate of another server, the client has
+	// none.
+	ca := newTLSServer(t, nil)
+	caConfig := ca.ClientTLSConfig()
+	srv := newTLSServer(t, &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  caConfig.RootCAs,
+	})
+
+	c, err := ssdb.Dial(srv.Addr, ssdb.WithTLSConfig(srv.ClientTLSConfig()))
+	if err == nil {
+		_, err = c.Get("a")
+		c.Close()
+	}
+	if err == nil {
+		t.Fatal("the server accepted a client without a certificate")
+	}
+}
+
+// redirectDialer connects to its address whatever address it is given.
+type redirectDialer string
+
+func (addr redirectDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, network, string(addr))
+}
+
+// hostCertificate returns a self-signed certificate valid for name only.
+func hostCertificate(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{name},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestTLSConnectHostname(t *testing.T) {
+	// Connect resolves the host, the certificate is verified against the
+	// host name and not the address it resolved to.
+	cert := hostCertificate(t, "localhost")
+	srv := newTLSServer(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+	c, err := ssdb.Connect("localhost", srv.Port(), "",
+		ssdb.WithTLSConfig(&tls.Config{RootCAs: roots}), ssdb.WithDialer(redirectDialer(srv.Addr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	testKV(t, c)
+}
@@ -0,0 +1,189 @@
+package ssdb
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultPort is the port of a ssdb:// URL without one.
+const DefaultPort = 8888
+
+// URLConfig is a connection URL parsed by ParseURL.
+type URLConfig struct {
+	// Network is "tcp" or "unix".
+	Network string
+	// Addr is "host:port", or the socket path for the unix network.
+	Addr     string
+	Password string
+	// PoolSize is the pool_size parameter, 0 when not set.
+	PoolSize int
+	// Options holds the client options of the URL, the password included.
+	Options []Option
+}
+
+// ParseURL parses a connection URL of the form
+//
+//	ssdb://:password@host:port?timeout=2s&zip=1&pool_size=20
+//	ssdb+unix://:password@/var/run/ssdb.sock?timeout=2s
+//
+// The query parameters are:
+//
+//	timeout        dial, read and write timeout, e.g. 2s
+//	dial_timeout   dial timeout
+//	read_timeout   read timeout
+//	write_timeout  write timeout
+//	keepalive      TCP keepalive period
+//	health_check   health check interval
+//	zip            1 or true to use the zip envelope
+//	pool_size      maximum number of connections of a pool
+//	password       password, instead of the user info
+//
+// timeout is applied first, the specific timeouts override it.
+func ParseURL(rawurl string) (*URLConfig, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &URLConfig{}
+	switch u.Scheme {
+	case "ssdb":
+		host, port := u.Hostname(), u.Port()
+		if host == "" {
+			return nil, fmt.Errorf("ssdb: missing host in URL %q", rawurl)
+		}
+		if port == "" {
+			port = strconv.Itoa(DefaultPort)
+		}
+		cfg.Network = "tcp"
+		cfg.Addr = net.JoinHostPort(host, port)
+	case "ssdb+unix":
+		if u.Path == "" {
+			return nil, fmt.Errorf("ssdb: missing socket path in URL %q", rawurl)
+		}
+		cfg.Network = "unix"
+		cfg.Addr = u.Path
+	default:
+		return nil, fmt.Errorf("ssdb: bad URL scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		if pwd, ok := u.User.Password(); ok {
+			cfg.Password = pwd
+		} else {
+			cfg.Password = u.User.Username()
+		}
+	}
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "timeout") != (keys[j] == "timeout") {
+			return keys[i] == "timeout"
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		vals := query[key]
+		val := vals[len(vals)-1]
+		var opt Option
+		switch key {
+		case "timeout", "dial_timeout", "read_timeout", "write_timeout", "keepalive", "health_check":
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("ssdb: bad %s in URL: %v", key, err)
+			}
+			switch key {
+			case "timeout":
+				cfg.Options = append(cfg.Options, WithDialTimeout(d), WithReadTimeout(d))
+				opt = WithWriteTimeout(d)
+			case "dial_timeout":
+				opt = WithDialTimeout(d)
+			case "read_timeout":
+				opt = WithReadTimeout(d)
+			case "write_timeout":
+				opt = WithWriteTimeout(d)
+			case "keepalive":
+				opt = WithKeepAlive(d)
+			case "health_check":
+				opt = WithHealthCheckInterval(d)
+			}
+		case "zip":
+			flag, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, fmt.Errorf("ssdb: bad zip in URL: %v", err)
+			}
+			opt = WithZip(flag)
+		case "pool_size":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("ssdb: bad pool_size in URL: %q", val)
+			}
+			cfg.PoolSize = n
+		case "password":
+			cfg.Password = val
+		default:
+			return nil, fmt.Errorf("ssdb: unknown URL parameter %q", key)
+		}
+		if opt != nil {
+			cfg.Options = append(cfg.Options, opt)
+		}
+	}
+	if cfg.Password != "" {
+		cfg.Options = append(cfg.Options, WithPassword(cfg.Password))
+	}
+	return cfg, nil
+}
+
+// Dial connects to the URL, opts are applied after the options of the URL.
+func (cfg *URLConfig) Dial(opts ...Option) (*Client, error) {
+	opts = append(append([]Option{}, cfg.Options...), opts...)
+	if cfg.Network == "unix" {
+		return DialUnix(cfg.Addr, opts...)
+	}
+	return Dial(cfg.Addr, opts...)
+}
+
+// DialURL connects to the server of a connection URL, see ParseURL.
+func DialURL(rawurl string, opts ...Option) (*Client, error) {
+	cfg, err := ParseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Dial(opts...)
+}
+
+// NewPoolURL creates a pool of connections to the server of a connection
+// URL. The pool_size parameter sets config.MaxActive and config.MaxIdle when
+// they are not set.
+func NewPoolURL(rawurl string, config PoolConfig) (*Pool, error) {
+	cfg, err := ParseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PoolSize > 0 {
+		if config.MaxActive == 0 {
+			config.MaxActive = cfg.PoolSize
+		}
+		if config.MaxIdle == 0 {
+			config.MaxIdle = cfg.PoolSize
+		}
+	}
+	if config.Dial == nil {
+		opts := config.Options
+		config.Dial = func() (*Client, error) {
+			return cfg.Dial(opts...)
+		}
+	}
+	host, port := cfg.Addr, 0
+	if cfg.Network == "tcp" {
+		h, p, _ := net.SplitHostPort(cfg.Addr)
+		host = h
+		port, _ = strconv.Atoi(p)
+	}
+	return NewPool(host, port, cfg.Password, config)
+}
@@ -0,0 +1,135 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+func TestParseURL(t *testing.T) {
+	for _, tt := range []struct {
+		url      string
+		network  string
+		addr     string
+		password string
+		poolSize int
+		options  int
+	}{
+		{"ssdb://:pw@db.local:9000", "tcp", "db.local:9000", "pw", 0, 1},
+		{"ssdb://pw@db.local:9000", "tcp", "db.local:9000", "pw", 0, 1},
+		{"ssdb://db.local", "tcp", "db.local:8888", "", 0, 0},
+		{"ssdb://[::1]", "tcp", "[::1]:8888", "", 0, 0},
+		{"ssdb://db.local?password=pw", "tcp", "db.local:8888", "pw", 0, 1},
+		{"ssdb+unix:///var/run/ssdb.sock", "unix", "/var/run/ssdb.sock", "", 0, 0},
+		{"ssdb+unix://:pw@/var/run/ssdb.sock?timeout=2s", "unix", "/var/run/ssdb.sock", "pw", 0, 4},
+		{"ssdb://db.local?timeout=2s", "tcp", "db.local:8888", "", 0, 3},
+		{"ssdb://db.local?timeout=2s&read_timeout=5s", "tcp", "db.local:8888", "", 0, 4},
+		{"ssdb://db.local?dial_timeout=1s&write_timeout=1s&keepalive=30s&health_check=10s", "tcp", "db.local:8888", "", 0, 4},
+		{"ssdb://db.local?zip=1", "tcp", "db.local:8888", "", 0, 1},
+		{"ssdb://db.local?zip=false", "tcp", "db.local:8888", "", 0, 1},
+		{"ssdb://db.local?pool_size=20", "tcp", "db.local:8888", "", 20, 0},
+		{"ssdb://db.local?pool_size=1&pool_size=5", "tcp", "db.local:8888", "", 5, 0},
+	} {
+		cfg, err := ssdb.ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseURL(%q): %v", tt.url, err)
+			continue
+		}
+		if cfg.Network != tt.network || cfg.Addr != tt.addr || cfg.Password != tt.password || cfg.PoolSize != tt.poolSize {
+			t.Errorf("ParseURL(%q) = %s %s %q pool %d, want %s %s %q pool %d", tt.url,
+				cfg.Network, cfg.Addr, cfg.Password, cfg.PoolSize, tt.network, tt.addr, tt.password, tt.poolSize)
+		}
+		if len(cfg.Options) != tt.options {
+			t.Errorf("ParseURL(%q) has %d options, want %d", tt.url, len(cfg.Options), tt.options)
+		}
+	}
+}
+
+func TestParseURLErrors(t *testing.T) {
+	for _, url := range []string{
+		"redis://db.local:6379",
+		"db.local:8888",
+		"ssdb+tcp://db.local",
+		"ssdb://",
+		"ssdb://:8888",
+		"ssdb+unix://",
+		"ssdb://db.local:port",
+		"ssdb://db.local?timeout=2",
+		"ssdb://db.local?read_timeout=soon",
+		"ssdb://db.local?keepalive=",
+		"ssdb://db.local?zip=maybe",
+		"ssdb://db.local?pool_size=many",
+		"ssdb://db.local?pool_size=-1",
+		"ssdb://db.local?tls=1",
+		"ssdb://db.local/%zz",
+	} {
+		if cfg, err := ssdb.ParseURL(url); err == nil {
+			t.Errorf("ParseURL(%q) = %+v, want an error", url, cfg)
+		}
+	}
+}
+
+func TestDialURL(t *testing.T) {
+	srv := newServer(t)
+	srv.SetPassword("pw")
+	url := "ssdb://:pw@" + srv.Addr + "?timeout=100ms&zip=1"
+	m := ssdb.NewMetricsCollector()
+	c, err := ssdb.DialURL(url, ssdb.WithMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	testKV(t, c)
+	if m.Snapshot().ZipRaw == 0 {
+		t.Error("zip=1 did not zip the commands")
+	}
+	srv.AddFault("get", ssdbtest.Fault{Latency: time.Second, Times: 1})
+	var nerr net.Error
+	if _, err := c.Get("a"); !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Errorf("Get past the timeout of the URL: %v", err)
+	}
+
+	if _, err := ssdb.DialURL("ssdb://:wrong@" + srv.Addr); err == nil {
+		t.Error("DialURL with a wrong password succeeded")
+	}
+
+	path := filepath.Join(t.TempDir(), "ssdb.sock")
+	usrv, err := ssdbtest.NewUnixServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer usrv.Close()
+	u, err := ssdb.DialURL("ssdb+unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+	testKV(t, u)
+}
+
+func TestNewPoolURL(t *testing.T) {
+	srv := newServer(t)
+	p, err := ssdb.NewPoolURL("ssdb://"+srv.Addr+"?pool_size=2", ssdb.PoolConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := p.Get(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if c, err := p.Get(ctx); err == nil {
+		p.Put(c)
+		t.Fatal("Get beyond pool_size succeeded")
+	}
+}
@@ -0,0 +1,329 @@
+package ssdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// ScoredMember is a key of a sorted set with its score.
+type ScoredMember struct {
+	Key   string
+	Score int64
+}
+
+// parseScored converts key/score pairs of a response into members, keeping
+// the order of the server.
+func parseScored(data []string) ([]ScoredMember, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("bad zset response size:%d", len(data))
+	}
+	members := make([]ScoredMember, 0, len(data)/2)
+	for i := 0; i < len(data); i += 2 {
+		score, err := strconv.ParseInt(data[i+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, ScoredMember{Key: data[i], Score: score})
+	}
+	return members, nil
+}
+
+func (c *Client) ZSet(name string, key string, score int64) error {
+	return c.ZSetContext(context.Background(), name, key, score)
+}
+
+func (c *Client) ZSetContext(ctx context.Context, name string, key string, score int64) error {
+	_, err := c.call(ctx, "zset", name, key, score)
+	return err
+}
+
+func (c *Client) ZGet(name string, key string) (int64, error) {
+	return c.ZGetContext(context.Background(), name, key)
+}
+
+func (c *Client) ZGetContext(ctx context.Context, name string, key string) (int64, error) {
+	data, err := c.call(ctx, "zget", name, key)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+func (c *Client) ZDel(name string, key string) error {
+	return c.ZDelContext(context.Background(), name, key)
+}
+
+func (c *Client) ZDelContext(ctx context.Context, name string, key string) error {
+	_, err := c.call(ctx, "zdel", name, key)
+	return err
+}
+
+// incr num to the score of key and return the new score
+func (c *Client) ZIncr(name string, key string, num int64) (int64, error) {
+	return c.ZIncrContext(context.Background(), name, key, num)
+}
+
+func (c *Client) ZIncrContext(ctx context.Context, name string, key string, num int64) (int64, error) {
+	data, err := c.call(ctx, "zincr", name, key, num)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+func (c *Client) ZSize(name string) (int64, error) {
+	return c.ZSizeContext(context.Background(), name)
+}
+
+func (c *Client) ZSizeContext(ctx context.Context, name string) (int64, error) {
+	data, err := c.call(ctx, "zsize", name)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// rank of key in ascending order of score, starting from 0
+func (c *Client) ZRank(name string, key string) (int64, error) {
+	return c.ZRankContext(context.Background(), name, key)
+}
+
+func (c *Client) ZRankContext(ctx context.Context, name string, key string) (int64, error) {
+	data, err := c.call(ctx, "zrank", name, key)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// rank of key in descending order of score, starting from 0
+func (c *Client) ZRRank(name string, key string) (int64, error) {
+	return c.ZRRankContext(context.Background(), name, key)
+}
+
+func (c *Client) ZRRankContext(ctx context.Context, name string, key string) (int64, error) {
+	data, err := c.call(ctx, "zrrank", name, key)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// get limit members from offset in ascending order of score
+func (c *Client) ZRange(name string, offset int, limit int) ([]ScoredMember, error) {
+	return c.ZRangeContext(context.Background(), name, offset, limit)
+}
+
+func (c *Client) ZRangeContext(ctx context.Context, name string, offset int, limit int) ([]ScoredMember, error) {
+	data, err := c.call(ctx, "zrange", name, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+// get limit members from offset in descending order of score
+func (c *Client) ZRRange(name string, offset int, limit int) ([]ScoredMember, error) {
+	return c.ZRRangeContext(context.Background(), name, offset, limit)
+}
+
+func (c *Client) ZRRangeContext(ctx context.Context, name string, offset int, limit int) ([]ScoredMember, error) {
+	data, err := c.call(ctx, "zrrange", name, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+// search members with score in (scoreStart,scoreEnd] after keyStart in ascending order,
+// an empty score means no limit
+func (c *Client) ZScan(name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	return c.ZScanContext(context.Background(), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (c *Client) ZScanContext(ctx context.Context, name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	data, err := c.call(ctx, "zscan", name, keyStart, scoreStart, scoreEnd, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+// same as ZScan in descending order
+func (c *Client) ZRScan(name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	return c.ZRScanContext(context.Background(), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (c *Client) ZRScanContext(ctx context.Context, name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]ScoredMember, error) {
+	data, err := c.call(ctx, "zrscan", name, keyStart, scoreStart, scoreEnd, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+// same as ZScan but only return the keys
+func (c *Client) ZKeys(name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]string, error) {
+	return c.ZKeysContext(context.Background(), name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+func (c *Client) ZKeysContext(ctx context.Context, name string, keyStart string, scoreStart string, scoreEnd string, limit int) ([]string, error) {
+	return c.call(ctx, "zkeys", name, keyStart, scoreStart, scoreEnd, limit)
+}
+
+// count members with score in [scoreStart,scoreEnd]
+func (c *Client) ZCount(name string, scoreStart string, scoreEnd string) (int64, error) {
+	return c.ZCountContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (c *Client) ZCountContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (int64, error) {
+	data, err := c.call(ctx, "zcount", name, scoreStart, scoreEnd)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// sum of the scores in [scoreStart,scoreEnd]
+func (c *Client) ZSum(name string, scoreStart string, scoreEnd string) (int64, error) {
+	return c.ZSumContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (c *Client) ZSumContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (int64, error) {
+	data, err := c.call(ctx, "zsum", name, scoreStart, scoreEnd)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// average of the scores in [scoreStart,scoreEnd]
+func (c *Client) ZAvg(name string, scoreStart string, scoreEnd string) (float64, error) {
+	return c.ZAvgContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (c *Client) ZAvgContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (float64, error) {
+	data, err := c.call(ctx, "zavg", name, scoreStart, scoreEnd)
+	if err != nil {
+		return 0, err
+	}
+	return parseFloat(data)
+}
+
+// delete members ranked in [start,end] and return the number of deleted members
+func (c *Client) ZRemRangeByRank(name string, start int, end int) (int64, error) {
+	return c.ZRemRangeByRankContext(context.Background(), name, start, end)
+}
+
+func (c *Client) ZRemRangeByRankContext(ctx context.Context, name string, start int, end int) (int64, error) {
+	data, err := c.call(ctx, "zremrangebyrank", name, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// delete members with score in [scoreStart,scoreEnd] and return the number of deleted members
+func (c *Client) ZRemRangeByScore(name string, scoreStart string, scoreEnd string) (int64, error) {
+	return c.ZRemRangeByScoreContext(context.Background(), name, scoreStart, scoreEnd)
+}
+
+func (c *Client) ZRemRangeByScoreContext(ctx context.Context, name string, scoreStart string, scoreEnd string) (int64, error) {
+	data, err := c.call(ctx, "zremrangebyscore", name, scoreStart, scoreEnd)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
+
+// delete and return limit members with the lowest scores
+func (c *Client) ZPopFront(name string, limit int) ([]ScoredMember, error) {
+	return c.ZPopFrontContext(context.Background(), name, limit)
+}
+
+func (c *Client) ZPopFrontContext(ctx context.Context, name string, limit int) ([]ScoredMember, error) {
+	data, err := c.call(ctx, "zpop_front", name, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+// delete and return limit members with the highest scores
+func (c *Client) ZPopBack(name string, limit int) ([]ScoredMember, error) {
+	return c.ZPopBackContext(context.Background(), name, limit)
+}
+
+func (c *Client) ZPopBackContext(ctx context.Context, name string, limit int) ([]ScoredMember, error) {
+	data, err := c.call(ctx, "zpop_back", name, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+func (c *Client) MultiZSet(name string, members []ScoredMember) error {
+	return c.MultiZSetContext(context.Background(), name, members)
+}
+
+func (c *Client) MultiZSetContext(ctx context.Context, name string, members []ScoredMember) error {
+	params := []interface{}{name}
+	for _, m := range members {
+		params = append(params, m.Key, m.Score)
+	}
+	_, err := c.call(ctx, "multi_zset", params...)
+	return err
+}
+
+// get the scores of keys, missing keys are skipped
+func (c *Client) MultiZGet(name string, keys []string) ([]ScoredMember, error) {
+	return c.MultiZGetContext(context.Background(), name, keys)
+}
+
+func (c *Client) MultiZGetContext(ctx context.Context, name string, keys []string) ([]ScoredMember, error) {
+	params := []interface{}{name}
+	for _, v := range keys {
+		params = append(params, v)
+	}
+	data, err := c.call(ctx, "multi_zget", params...)
+	if err != nil {
+		return nil, err
+	}
+	return parseScored(data)
+}
+
+func (c *Client) MultiZDel(name string, keys []string) error {
+	return c.MultiZDelContext(context.Background(), name, keys)
+}
+
+func (c *Client) MultiZDelContext(ctx context.Context, name string, keys []string) error {
+	params := []interface{}{name}
+	for _, v := range keys {
+		params = append(params, v)
+	}
+	_, err := c.call(ctx, "multi_zdel", params...)
+	return err
+}
+
+// search from start to end zset name,except start word
+func (c *Client) ZList(start string, end string, limit int) ([]string, error) {
+	return c.ZListContext(context.Background(), start, end, limit)
+}
+
+func (c *Client) ZListContext(ctx context.Context, start string, end string, limit int) ([]string, error) {
+	return c.call(ctx, "zlist", start, end, limit)
+}
+
+// delete all members of name and return the number of deleted members
+func (c *Client) ZClear(name string) (int64, error) {
+	return c.ZClearContext(context.Background(), name)
+}
+
+func (c *Client) ZClearContext(ctx context.Context, name string) (int64, error) {
+	data, err := c.call(ctx, "zclear", name)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(data)
+}
@@ -0,0 +1,103 @@
+package ssdb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/matishsiao/gossdb/ssdb"
+	"github.com/matishsiao/gossdb/ssdb/ssdbtest"
+)
+
+// newZSet returns a client whose sorted set z holds, in ascending order,
+// e:-5 b:1 c:2 d:2 a:3.
+func newZSet(t *testing.T) (*ssdb.Client, *ssdbtest.Server) {
+	t.Helper()
+	srv := newServer(t)
+	c := dial(t, srv)
+	members := []ssdb.ScoredMember{{"a", 3}, {"b", 1}, {"c", 2}, {"d", 2}, {"e", -5}}
+	if err := c.MultiZSet("z", members); err != nil {
+		t.Fatal(err)
+	}
+	return c, srv
+}
+
+func checkMembers(t *testing.T, what string, got []ssdb.ScoredMember, err error, want ...ssdb.ScoredMember) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if want == nil {
+		want = []ssdb.ScoredMember{}
+	}
+	if got == nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s = %v, want %v", what, got, want)
+	}
+}
+
+func TestZRange(t *testing.T) {
+	c, _ := newZSet(t)
+	e, b, cc, d, a := ssdb.ScoredMember{"e", -5}, ssdb.ScoredMember{"b", 1}, ssdb.ScoredMember{"c", 2}, ssdb.ScoredMember{"d", 2}, ssdb.ScoredMember{"a", 3}
+
+	got, err := c.ZRange("z", 0, 10)
+	checkMembers(t, "ZRange", got, err, e, b, cc, d, a)
+	got, err = c.ZRange("z", 1, 2)
+	checkMembers(t, "ZRange from 1", got, err, b, cc)
+	got, err = c.ZRange("z", 5, 10)
+	checkMembers(t, "ZRange past the end", got, err)
+	got, err = c.ZRRange("z", 0, 10)
+	checkMembers(t, "ZRRange", got, err, a, d, cc, b, e)
+	got, err = c.ZRRange("z", 1, 2)
+	checkMembers(t, "ZRRange from 1", got, err, d, cc)
+	got, err = c.ZRange("missing", 0, 10)
+	checkMembers(t, "ZRange of a missing set", got, err)
+}
+
+func TestZScan(t *testing.T) {
+	c, _ := newZSet(t)
+	e, b, cc, d, a := ssdb.ScoredMember{"e", -5}, ssdb.ScoredMember{"b", 1}, ssdb.ScoredMember{"c", 2}, ssdb.ScoredMember{"d", 2}, ssdb.ScoredMember{"a", 3}
+
+	got, err := c.ZScan("z", "", "", "", -1)
+	checkMembers(t, "ZScan", got, err, e, b, cc, d, a)
+	got, err = c.ZScan("z", "", "1", "2", 10)
+	checkMembers(t, "ZScan of [1,2]", got, err, b, cc, d)
+	// The scan resumes after the last member returned.
+	got, err = c.ZScan("z", "c", "2", "", 10)
+	checkMembers(t, "ZScan after c:2", got, err, d, a)
+	got, err = c.ZScan("z", "", "", "", 2)
+	checkMembers(t, "ZScan with a limit", got, err, e, b)
+	got, err = c.ZRScan("z", "", "", "", -1)
+	checkMembers(t, "ZRScan", got, err, a, d, cc, b, e)
+	got, err = c.ZRScan("z", "d", "2", "", 10)
+	checkMembers(t, "ZRScan after d:2", got, err, cc, b, e)
+	keys, err := c.ZKeys("z", "", "1", "2", 10)
+	if err != nil || !reflect.DeepEqual(keys, []string{"b", "c", "d"}) {
+		t.Fatalf("ZKeys = %q, %v", keys, err)
+	}
+}
+
+func TestZPop(t *testing.T) {
+	c, _ := newZSet(t)
+	got, err := c.ZPopFront("z", 2)
+	checkMembers(t, "ZPopFront", got, err, ssdb.ScoredMember{"e", -5}, ssdb.ScoredMember{"b", 1})
+	got, err = c.ZPopBack("z", 2)
+	checkMembers(t, "ZPopBack", got, err, ssdb.ScoredMember{"a", 3}, ssdb.ScoredMember{"d", 2})
+	if n, err := c.ZSize("z"); err != nil || n != 1 {
+		t.Fatalf("ZSize after the pops = %d, %v, want 1", n, err)
+	}
+	got, err = c.ZPopBack("z", 10)
+	checkMembers(t, "ZPopBack of the last member", got, err, ssdb.ScoredMember{"c", 2})
+	got, err = c.ZPopFront("z", 10)
+	checkMembers(t, "ZPopFront of an empty set", got, err)
+}
+
+func TestZRangeBadResponse(t *testing.T) {
+	c, srv := newZSet(t)
+	srv.AddFault("zrange", ssdbtest.Fault{Response: []string{"ok", "a", "1", "b"}, Times: 1})
+	if got, err := c.ZRange("z", 0, 10); err == nil {
+		t.Fatalf("ZRange of an odd response = %v", got)
+	}
+	srv.AddFault("zpop_front", ssdbtest.Fault{Response: []string{"ok", "a", "high"}, Times: 1})
+	if got, err := c.ZPopFront("z", 1); err == nil {
+		t.Fatalf("ZPopFront with a bad score = %v", got)
+	}
+}